@@ -0,0 +1,1694 @@
+// Package api provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for DeliveryStatus.
+const (
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+	DeliveryStatusPending   DeliveryStatus = "pending"
+)
+
+// Defines values for ReservationStatus.
+const (
+	ReservationStatusCanceled    ReservationStatus = "canceled"
+	ReservationStatusDispatched  ReservationStatus = "dispatched"
+	ReservationStatusDispatching ReservationStatus = "dispatching"
+	ReservationStatusFailed      ReservationStatus = "failed"
+	ReservationStatusScheduled   ReservationStatus = "scheduled"
+)
+
+// Defines values for TripStatus.
+const (
+	CANCELED     TripStatus = "CANCELED"
+	DRIVERFOUND  TripStatus = "DRIVER_FOUND"
+	DRIVERSEARCH TripStatus = "DRIVER_SEARCH"
+	ENDED        TripStatus = "ENDED"
+	ONPOSITION   TripStatus = "ON_POSITION"
+	STARTED      TripStatus = "STARTED"
+)
+
+// Defines values for ListReservationsParamsStatus.
+const (
+	ListReservationsParamsStatusCanceled    ListReservationsParamsStatus = "canceled"
+	ListReservationsParamsStatusDispatched  ListReservationsParamsStatus = "dispatched"
+	ListReservationsParamsStatusDispatching ListReservationsParamsStatus = "dispatching"
+	ListReservationsParamsStatusFailed      ListReservationsParamsStatus = "failed"
+	ListReservationsParamsStatusScheduled   ListReservationsParamsStatus = "scheduled"
+)
+
+// Defines values for ListTripsParamsSort.
+const (
+	CreatedAt      ListTripsParamsSort = "created_at"
+	MinusCreatedAt ListTripsParamsSort = "-created_at"
+)
+
+// Defines values for ListDeliveriesParamsStatus.
+const (
+	ListDeliveriesParamsStatusDelivered ListDeliveriesParamsStatus = "delivered"
+	ListDeliveriesParamsStatusFailed    ListDeliveriesParamsStatus = "failed"
+	ListDeliveriesParamsStatusPending   ListDeliveriesParamsStatus = "pending"
+)
+
+// CancelResult defines model for CancelResult.
+type CancelResult struct {
+	// Fee Cancellation fee, absent when the cancellation is free. The trip service charges it
+	// as cancel_fee of the trip or rejects the cancellation with trip.event.rejected
+	Fee *Money `json:"fee,omitempty"`
+
+	// Status Trip status the fee was computed for, as last seen by this service
+	Status *string             `json:"status,omitempty"`
+	TripId *openapi_types.UUID `json:"trip_id,omitempty"`
+}
+
+// Delivery defines model for Delivery.
+type Delivery struct {
+	Attempts *[]struct {
+		At         *time.Time `json:"at,omitempty"`
+		DurationMs *int       `json:"duration_ms,omitempty"`
+		Error      *string    `json:"error,omitempty"`
+		StatusCode *int       `json:"status_code,omitempty"`
+	} `json:"attempts,omitempty"`
+	CreatedAt     *time.Time          `json:"created_at,omitempty"`
+	EventId       *string             `json:"event_id,omitempty"`
+	EventType     *string             `json:"event_type,omitempty"`
+	Id            *openapi_types.UUID `json:"id,omitempty"`
+	NextAttemptAt *time.Time          `json:"next_attempt_at,omitempty"`
+	Status        *DeliveryStatus     `json:"status,omitempty"`
+	WebhookId     *openapi_types.UUID `json:"webhook_id,omitempty"`
+}
+
+// DeliveryStatus defines model for Delivery.Status.
+type DeliveryStatus string
+
+// LatLngLiteral An object describing a specific location with Latitude and Longitude in decimal degrees.
+type LatLngLiteral struct {
+	// Lat Latitude in decimal degrees
+	Lat float32 `json:"lat"`
+
+	// Lng Longitude in decimal degrees
+	Lng float32 `json:"lng"`
+}
+
+// Money defines model for Money.
+type Money struct {
+	// Amount Amount expressed as a decimal number of major currency units
+	Amount float64 `json:"amount"`
+
+	// Currency 3 letter currency code as defined by ISO-4217
+	Currency string `json:"currency"`
+}
+
+// Offer Terms offered to the client by the Offering service
+type Offer struct {
+	ClientId *string `json:"client_id,omitempty"`
+
+	// From An object describing a specific location with Latitude and Longitude in decimal degrees.
+	From *LatLngLiteral `json:"from,omitempty"`
+
+	// Id Signed offer, valid for 8 hours
+	Id    *string `json:"id,omitempty"`
+	Price *Money  `json:"price,omitempty"`
+
+	// To An object describing a specific location with Latitude and Longitude in decimal degrees.
+	To        *LatLngLiteral   `json:"to,omitempty"`
+	Waypoints *[]LatLngLiteral `json:"waypoints,omitempty"`
+}
+
+// Place defines model for Place.
+type Place struct {
+	Address   *string             `json:"address,omitempty"`
+	CreatedAt *time.Time          `json:"created_at,omitempty"`
+	Id        *openapi_types.UUID `json:"id,omitempty"`
+
+	// Location An object describing a specific location with Latitude and Longitude in decimal degrees.
+	Location  *LatLngLiteral `json:"location,omitempty"`
+	Name      *string        `json:"name,omitempty"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+}
+
+// PlaceInput defines model for PlaceInput.
+type PlaceInput struct {
+	Address *string `json:"address,omitempty"`
+
+	// Location An object describing a specific location with Latitude and Longitude in decimal degrees.
+	Location LatLngLiteral `json:"location"`
+
+	// Name Unique among the user's places
+	Name string `json:"name"`
+}
+
+// Problem Error in RFC 7807 format, served as application/problem+json. Branch on code,
+// detail is a human-readable message and may change.
+type Problem struct {
+	// Code Stable machine-readable error code:
+	// * invalid_request - body, path or query parameters are incorrect
+	// * unauthorized, invalid_token - missing or invalid bearer token
+	// * offer_invalid, offer_expired - offer can not be used, request a new one for offer_expired
+	// * offer_wrong_user - offer was issued to another client
+	// * too_many_waypoints - route rejected by the Offering service
+	// * offering_unavailable - Offering service is down or answered out of contract (502)
+	// * trip_not_found, trip_not_cancelable
+	// * invalid_scheduled_at
+	// * reservation_not_found, reservation_not_cancelable
+	// * place_not_found, place_exists
+	// * webhook_not_found
+	// * idempotency_key_reused, idempotency_in_progress
+	// * internal_error
+	Code   string  `json:"code"`
+	Detail *string `json:"detail,omitempty"`
+	Status int     `json:"status"`
+
+	// Title HTTP status text
+	Title string `json:"title"`
+
+	// Type urn:problem:<code>
+	Type string `json:"type"`
+}
+
+// Reservation defines model for Reservation.
+type Reservation struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// DispatchAt When the trip is created and the driver search starts
+	DispatchAt *time.Time `json:"dispatch_at,omitempty"`
+
+	// From An object describing a specific location with Latitude and Longitude in decimal degrees.
+	From      *LatLngLiteral      `json:"from,omitempty"`
+	Id        *openapi_types.UUID `json:"id,omitempty"`
+	LastError *string             `json:"last_error,omitempty"`
+
+	// OfferId Current offer, replaced when the original one is requoted
+	OfferId     *string            `json:"offer_id,omitempty"`
+	Price       *Money             `json:"price,omitempty"`
+	ScheduledAt *time.Time         `json:"scheduled_at,omitempty"`
+	Status      *ReservationStatus `json:"status,omitempty"`
+
+	// To An object describing a specific location with Latitude and Longitude in decimal degrees.
+	To *LatLngLiteral `json:"to,omitempty"`
+
+	// TripId ID the trip will be created with
+	TripId    *openapi_types.UUID `json:"trip_id,omitempty"`
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"`
+
+	// Waypoints Intermediate stops in visiting order
+	Waypoints *[]LatLngLiteral `json:"waypoints,omitempty"`
+}
+
+// ReservationStatus defines model for Reservation.Status.
+type ReservationStatus string
+
+// Route defines model for Route.
+type Route struct {
+	// From Point of a route, a saved place if place_id is set, otherwise lat and lng
+	From Stop `json:"from"`
+
+	// To Point of a route, a saved place if place_id is set, otherwise lat and lng
+	To Stop `json:"to"`
+
+	// Waypoints Intermediate stops in visiting order
+	Waypoints *[]Stop `json:"waypoints,omitempty"`
+}
+
+// Stop Point of a route, a saved place if place_id is set, otherwise lat and lng
+type Stop struct {
+	Lat     *float32            `json:"lat,omitempty"`
+	Lng     *float32            `json:"lng,omitempty"`
+	PlaceId *openapi_types.UUID `json:"place_id,omitempty"`
+}
+
+// TimelineEntry defines model for TimelineEntry.
+type TimelineEntry struct {
+	EventId *string    `json:"event_id,omitempty"`
+	Status  *string    `json:"status,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+
+	// Type CloudEvent type of the trip event
+	Type *string `json:"type,omitempty"`
+}
+
+// Trip defines model for Trip.
+type Trip struct {
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	ArrivedAt    *time.Time `json:"arrived_at,omitempty"`
+	CancelFee    *Money     `json:"cancel_fee,omitempty"`
+	CancelReason *string    `json:"cancel_reason,omitempty"`
+	CanceledAt   *time.Time `json:"canceled_at,omitempty"`
+
+	// CanceledBy Who canceled the trip, client for cancellations through this API
+	CanceledBy *string    `json:"canceled_by,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+
+	// DriverId Set when a driver accepts the trip
+	DriverId *string    `json:"driver_id,omitempty"`
+	EndedAt  *time.Time `json:"ended_at,omitempty"`
+
+	// From An object describing a specific location with Latitude and Longitude in decimal degrees.
+	From    *LatLngLiteral      `json:"from,omitempty"`
+	Id      *openapi_types.UUID `json:"id,omitempty"`
+	OfferId *string             `json:"offer_id,omitempty"`
+	Price   *Money              `json:"price,omitempty"`
+
+	// ScheduledAt Pickup time of a booked trip
+	ScheduledAt *time.Time  `json:"scheduled_at,omitempty"`
+	StartedAt   *time.Time  `json:"started_at,omitempty"`
+	Status      *TripStatus `json:"status,omitempty"`
+
+	// To An object describing a specific location with Latitude and Longitude in decimal degrees.
+	To        *LatLngLiteral `json:"to,omitempty"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+
+	// Waypoints Intermediate stops in visiting order
+	Waypoints *[]LatLngLiteral `json:"waypoints,omitempty"`
+}
+
+// TripStatus defines model for Trip.Status.
+type TripStatus string
+
+// TripUpdate defines model for TripUpdate.
+type TripUpdate struct {
+	// Event CloudEvent type, empty in the first message
+	Event *string `json:"event,omitempty"`
+
+	// Status Trip status after the event, empty if the event does not change it
+	Status *string             `json:"status,omitempty"`
+	Time   *time.Time          `json:"time,omitempty"`
+	TripId *openapi_types.UUID `json:"trip_id,omitempty"`
+}
+
+// Webhook defines model for Webhook.
+type Webhook struct {
+	CreatedAt *time.Time          `json:"created_at,omitempty"`
+	Events    *[]string           `json:"events,omitempty"`
+	Id        *openapi_types.UUID `json:"id,omitempty"`
+
+	// Secret HMAC key, only in the response to createWebhook
+	Secret *string `json:"secret,omitempty"`
+	Url    *string `json:"url,omitempty"`
+}
+
+// Unauthorized Error in RFC 7807 format, served as application/problem+json. Branch on code,
+// detail is a human-readable message and may change.
+type Unauthorized = Problem
+
+// ListReservationsParams defines parameters for ListReservations.
+type ListReservationsParams struct {
+	Status *ListReservationsParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+}
+
+// ListReservationsParamsStatus defines parameters for ListReservations.
+type ListReservationsParamsStatus string
+
+// CancelReservationParams defines parameters for CancelReservation.
+type CancelReservationParams struct {
+	// IdempotencyKey Client-generated unique key. A retry with the same key and body returns the stored
+	// response with Idempotent-Replayed: true; keys are kept for 24 hours.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// ListTripsParams defines parameters for ListTrips.
+type ListTripsParams struct {
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+
+	// CreatedFrom Trips created at or after this time
+	CreatedFrom *time.Time `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Trips created before this time
+	CreatedTo *time.Time           `form:"created_to,omitempty" json:"created_to,omitempty"`
+	PriceMin  *float64             `form:"price_min,omitempty" json:"price_min,omitempty"`
+	PriceMax  *float64             `form:"price_max,omitempty" json:"price_max,omitempty"`
+	Sort      *ListTripsParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+	Limit     *int                 `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor X-Next-Cursor of the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// ListTripsParamsSort defines parameters for ListTrips.
+type ListTripsParamsSort string
+
+// CreateTripJSONBody defines parameters for CreateTrip.
+type CreateTripJSONBody struct {
+	// From Point of a route, a saved place if place_id is set, otherwise lat and lng
+	From *Stop `json:"from,omitempty"`
+
+	// OfferId id of offer from Offering service
+	OfferId *string `json:"offer_id,omitempty"`
+
+	// ScheduledAt Pickup time, from 15 minutes to 30 days ahead. Omit for an immediate trip
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// To Point of a route, a saved place if place_id is set, otherwise lat and lng
+	To        *Stop   `json:"to,omitempty"`
+	Waypoints *[]Stop `json:"waypoints,omitempty"`
+}
+
+// CreateTripParams defines parameters for CreateTrip.
+type CreateTripParams struct {
+	// IdempotencyKey Client-generated unique key. A retry with the same key and body returns the stored
+	// response with Idempotent-Replayed: true; keys are kept for 24 hours.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// TripEventsParams defines parameters for TripEvents.
+type TripEventsParams struct {
+	// AccessToken Bearer token for clients that cannot set the Authorization header
+	AccessToken *string `form:"access_token,omitempty" json:"access_token,omitempty"`
+
+	// LastEventID Id of the last received event
+	LastEventID *int `json:"Last-Event-ID,omitempty"`
+}
+
+// CancelTripParams defines parameters for CancelTrip.
+type CancelTripParams struct {
+	// Reason Reason for trip cancellation
+	Reason string `form:"reason" json:"reason"`
+
+	// IdempotencyKey Client-generated unique key. A retry with the same key and body returns the stored
+	// response with Idempotent-Replayed: true; keys are kept for 24 hours.
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// TripUpdatesParams defines parameters for TripUpdates.
+type TripUpdatesParams struct {
+	// AccessToken Bearer token for clients that cannot set the Authorization header
+	AccessToken *string `form:"access_token,omitempty" json:"access_token,omitempty"`
+}
+
+// CreateWebhookJSONBody defines parameters for CreateWebhook.
+type CreateWebhookJSONBody struct {
+	// Events CloudEvent types to deliver, all events if empty
+	Events *[]string `json:"events,omitempty"`
+
+	// Url https URL of a public host. Loopback, private, link-local and other
+	// non-public addresses are rejected here and again on every delivery
+	Url string `json:"url"`
+}
+
+// ListDeliveriesParams defines parameters for ListDeliveries.
+type ListDeliveriesParams struct {
+	Status *ListDeliveriesParamsStatus `form:"status,omitempty" json:"status,omitempty"`
+	Limit  *int                        `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// ListDeliveriesParamsStatus defines parameters for ListDeliveries.
+type ListDeliveriesParamsStatus string
+
+// CreateOfferJSONRequestBody defines body for CreateOffer for application/json ContentType.
+type CreateOfferJSONRequestBody = Route
+
+// CreatePlaceJSONRequestBody defines body for CreatePlace for application/json ContentType.
+type CreatePlaceJSONRequestBody = PlaceInput
+
+// UpdatePlaceJSONRequestBody defines body for UpdatePlace for application/json ContentType.
+type UpdatePlaceJSONRequestBody = PlaceInput
+
+// CreateTripJSONRequestBody defines body for CreateTrip for application/json ContentType.
+type CreateTripJSONRequestBody CreateTripJSONBody
+
+// CreateWebhookJSONRequestBody defines body for CreateWebhook for application/json ContentType.
+type CreateWebhookJSONRequestBody CreateWebhookJSONBody
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Request an offer for a route
+	// (POST /offers)
+	CreateOffer(w http.ResponseWriter, r *http.Request)
+	// List saved places of the user, by name
+	// (GET /places)
+	ListPlaces(w http.ResponseWriter, r *http.Request)
+	// Save a place
+	// (POST /places)
+	CreatePlace(w http.ResponseWriter, r *http.Request)
+	// Delete saved place
+	// (DELETE /places/{place_id})
+	DeletePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID)
+	// Get saved place
+	// (GET /places/{place_id})
+	GetPlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID)
+	// Replace name, address and location of the place
+	// (PUT /places/{place_id})
+	UpdatePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID)
+	// List booked trips
+	// (GET /reservations)
+	ListReservations(w http.ResponseWriter, r *http.Request, params ListReservationsParams)
+	// Cancel booked trip
+	// (POST /reservations/{reservation_id}/cancel)
+	CancelReservation(w http.ResponseWriter, r *http.Request, reservationId openapi_types.UUID, params CancelReservationParams)
+	// List trips
+	// (GET /trips)
+	ListTrips(w http.ResponseWriter, r *http.Request, params ListTripsParams)
+	// Create trip
+	// (POST /trips)
+	CreateTrip(w http.ResponseWriter, r *http.Request, params CreateTripParams)
+	// Stream trip status changes
+	// (GET /trips/events)
+	TripEvents(w http.ResponseWriter, r *http.Request, params TripEventsParams)
+	// Get trip by ID
+	// (GET /trips/{trip_id})
+	GetTripByID(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID)
+	// Cancel trip
+	// (POST /trips/{trip_id}/cancel)
+	CancelTrip(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, params CancelTripParams)
+	// Trip status timeline
+	// (GET /trips/{trip_id}/timeline)
+	TripTimeline(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID)
+	// Subscribe to trip status changes
+	// (GET /trips/{trip_id}/ws)
+	TripUpdates(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, params TripUpdatesParams)
+	// List webhooks of the user
+	// (GET /webhooks)
+	ListWebhooks(w http.ResponseWriter, r *http.Request)
+	// Subscribe an HTTP endpoint to trip events
+	// (POST /webhooks)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	// Delete webhook, pending deliveries are marked failed
+	// (DELETE /webhooks/{webhook_id})
+	DeleteWebhook(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID)
+	// Delivery log of the webhook, newest first
+	// (GET /webhooks/{webhook_id}/deliveries)
+	ListDeliveries(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID, params ListDeliveriesParams)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// Request an offer for a route
+// (POST /offers)
+func (_ Unimplemented) CreateOffer(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List saved places of the user, by name
+// (GET /places)
+func (_ Unimplemented) ListPlaces(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Save a place
+// (POST /places)
+func (_ Unimplemented) CreatePlace(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Delete saved place
+// (DELETE /places/{place_id})
+func (_ Unimplemented) DeletePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get saved place
+// (GET /places/{place_id})
+func (_ Unimplemented) GetPlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Replace name, address and location of the place
+// (PUT /places/{place_id})
+func (_ Unimplemented) UpdatePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List booked trips
+// (GET /reservations)
+func (_ Unimplemented) ListReservations(w http.ResponseWriter, r *http.Request, params ListReservationsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Cancel booked trip
+// (POST /reservations/{reservation_id}/cancel)
+func (_ Unimplemented) CancelReservation(w http.ResponseWriter, r *http.Request, reservationId openapi_types.UUID, params CancelReservationParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List trips
+// (GET /trips)
+func (_ Unimplemented) ListTrips(w http.ResponseWriter, r *http.Request, params ListTripsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Create trip
+// (POST /trips)
+func (_ Unimplemented) CreateTrip(w http.ResponseWriter, r *http.Request, params CreateTripParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Stream trip status changes
+// (GET /trips/events)
+func (_ Unimplemented) TripEvents(w http.ResponseWriter, r *http.Request, params TripEventsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get trip by ID
+// (GET /trips/{trip_id})
+func (_ Unimplemented) GetTripByID(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Cancel trip
+// (POST /trips/{trip_id}/cancel)
+func (_ Unimplemented) CancelTrip(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, params CancelTripParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Trip status timeline
+// (GET /trips/{trip_id}/timeline)
+func (_ Unimplemented) TripTimeline(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Subscribe to trip status changes
+// (GET /trips/{trip_id}/ws)
+func (_ Unimplemented) TripUpdates(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, params TripUpdatesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// List webhooks of the user
+// (GET /webhooks)
+func (_ Unimplemented) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Subscribe an HTTP endpoint to trip events
+// (POST /webhooks)
+func (_ Unimplemented) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Delete webhook, pending deliveries are marked failed
+// (DELETE /webhooks/{webhook_id})
+func (_ Unimplemented) DeleteWebhook(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Delivery log of the webhook, newest first
+// (GET /webhooks/{webhook_id}/deliveries)
+func (_ Unimplemented) ListDeliveries(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID, params ListDeliveriesParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// CreateOffer operation middleware
+func (siw *ServerInterfaceWrapper) CreateOffer(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateOffer(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListPlaces operation middleware
+func (siw *ServerInterfaceWrapper) ListPlaces(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListPlaces(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreatePlace operation middleware
+func (siw *ServerInterfaceWrapper) CreatePlace(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreatePlace(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeletePlace operation middleware
+func (siw *ServerInterfaceWrapper) DeletePlace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "place_id" -------------
+	var placeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "place_id", chi.URLParam(r, "place_id"), &placeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "place_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeletePlace(w, r, placeId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetPlace operation middleware
+func (siw *ServerInterfaceWrapper) GetPlace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "place_id" -------------
+	var placeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "place_id", chi.URLParam(r, "place_id"), &placeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "place_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetPlace(w, r, placeId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdatePlace operation middleware
+func (siw *ServerInterfaceWrapper) UpdatePlace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "place_id" -------------
+	var placeId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "place_id", chi.URLParam(r, "place_id"), &placeId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "place_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdatePlace(w, r, placeId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListReservations operation middleware
+func (siw *ServerInterfaceWrapper) ListReservations(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListReservationsParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListReservations(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CancelReservation operation middleware
+func (siw *ServerInterfaceWrapper) CancelReservation(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "reservation_id" -------------
+	var reservationId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "reservation_id", chi.URLParam(r, "reservation_id"), &reservationId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "reservation_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CancelReservationParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CancelReservation(w, r, reservationId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListTrips operation middleware
+func (siw *ServerInterfaceWrapper) ListTrips(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListTripsParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_from", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_to", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "price_min" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "price_min", r.URL.Query(), &params.PriceMin)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "price_min", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "price_max" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "price_max", r.URL.Query(), &params.PriceMax)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "price_max", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListTrips(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateTrip operation middleware
+func (siw *ServerInterfaceWrapper) CreateTrip(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateTripParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateTrip(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// TripEvents operation middleware
+func (siw *ServerInterfaceWrapper) TripEvents(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params TripEventsParams
+
+	// ------------- Optional query parameter "access_token" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "access_token", r.URL.Query(), &params.AccessToken)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "access_token", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID int
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.TripEvents(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetTripByID operation middleware
+func (siw *ServerInterfaceWrapper) GetTripByID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "trip_id" -------------
+	var tripId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "trip_id", chi.URLParam(r, "trip_id"), &tripId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "trip_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetTripByID(w, r, tripId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CancelTrip operation middleware
+func (siw *ServerInterfaceWrapper) CancelTrip(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "trip_id" -------------
+	var tripId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "trip_id", chi.URLParam(r, "trip_id"), &tripId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "trip_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CancelTripParams
+
+	// ------------- Required query parameter "reason" -------------
+
+	if paramValue := r.URL.Query().Get("reason"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "reason"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "reason", r.URL.Query(), &params.Reason)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "reason", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Idempotency-Key", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Idempotency-Key", valueList[0], &IdempotencyKey, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Idempotency-Key", Err: err})
+			return
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CancelTrip(w, r, tripId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// TripTimeline operation middleware
+func (siw *ServerInterfaceWrapper) TripTimeline(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "trip_id" -------------
+	var tripId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "trip_id", chi.URLParam(r, "trip_id"), &tripId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "trip_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.TripTimeline(w, r, tripId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// TripUpdates operation middleware
+func (siw *ServerInterfaceWrapper) TripUpdates(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "trip_id" -------------
+	var tripId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "trip_id", chi.URLParam(r, "trip_id"), &tripId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "trip_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params TripUpdatesParams
+
+	// ------------- Optional query parameter "access_token" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "access_token", r.URL.Query(), &params.AccessToken)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "access_token", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.TripUpdates(w, r, tripId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListWebhooks operation middleware
+func (siw *ServerInterfaceWrapper) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListWebhooks(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateWebhook operation middleware
+func (siw *ServerInterfaceWrapper) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateWebhook(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteWebhook operation middleware
+func (siw *ServerInterfaceWrapper) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "webhook_id" -------------
+	var webhookId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "webhook_id", chi.URLParam(r, "webhook_id"), &webhookId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "webhook_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteWebhook(w, r, webhookId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListDeliveries operation middleware
+func (siw *ServerInterfaceWrapper) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "webhook_id" -------------
+	var webhookId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "webhook_id", chi.URLParam(r, "webhook_id"), &webhookId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "webhook_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListDeliveriesParams
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListDeliveries(w, r, webhookId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/offers", wrapper.CreateOffer)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/places", wrapper.ListPlaces)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/places", wrapper.CreatePlace)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/places/{place_id}", wrapper.DeletePlace)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/places/{place_id}", wrapper.GetPlace)
+	})
+	r.Group(func(r chi.Router) {
+		r.Put(options.BaseURL+"/places/{place_id}", wrapper.UpdatePlace)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/reservations", wrapper.ListReservations)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/reservations/{reservation_id}/cancel", wrapper.CancelReservation)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/trips", wrapper.ListTrips)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/trips", wrapper.CreateTrip)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/trips/events", wrapper.TripEvents)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/trips/{trip_id}", wrapper.GetTripByID)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/trips/{trip_id}/cancel", wrapper.CancelTrip)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/trips/{trip_id}/timeline", wrapper.TripTimeline)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/trips/{trip_id}/ws", wrapper.TripUpdates)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/webhooks", wrapper.ListWebhooks)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/webhooks", wrapper.CreateWebhook)
+	})
+	r.Group(func(r chi.Router) {
+		r.Delete(options.BaseURL+"/webhooks/{webhook_id}", wrapper.DeleteWebhook)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/webhooks/{webhook_id}/deliveries", wrapper.ListDeliveries)
+	})
+
+	return r
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+w9XXMcx3F/ZWqTqoDJHgBSpGhClQeKpCzYtMgCwNBVOhZqbrfvboTdmfXMLICLClUS",
+	"XSk92LFe8uxUkj9AM2ZEUyL0F/b+Uap7Zr/u9oADCBKmyi8Sbnd2pqenv6a/+GUQqTRTEqQ1wcaXgQaT",
+	"KWmAfjySPLdjpcW/Qoy/IyUtSIt/8ixLRMStUHIt02qQQPpPXxgl8Z2JxpBy/OvvNQyDjeDv1upF1txb",
+	"s/bQfRUcHR2FQQwm0iLD6YKN4FfCGCFHTGkm5D5PRMwGwDVoZtUeyJBFKgaWN6BrDN2lMUEYjIHHoGkj",
+	"jx8/7t3O7RikRaChDaWdZBBsBMZqIUcID0LkwcT3d7iMINkCkye090yrDLQVDklDoOl4kjwYBhufn7zp",
+	"XykJk+DoyeyO3RIJ4ZMNAULGBwakZQdjkMyOgUXNEcKwoQZYZTtjYFaLjBnQ+yICFo25HoFhwvYlN/6r",
+	"3SEAU0Nmy9FKMw1fQGTN/NwHwo5p1Crsg7SrbiDEASLFcpvTrtvg7xAI9JImxPUOcHmVZrmFmA2VDhk3",
+	"LOHGMgMg2WDC7FiYEvAgnD2GMEAgdgVR3lDplNtgI8hzEc+PPaqeqAFCi1/fhUTsg57Mnxi3FtLM0buw",
+	"kJquIa1VY26hZ0XaCWaca8LcrpvIvxfSwgg0DgCtle4gtBKfu0jOXZ92bcs/4FrzCf6ONHAL8e5ZIKaD",
+	"9Zhd8NI97ni91HmEgYRDu+sRfSbYahIDmafBxudBBjLGl8gzdKSAKw65SCAOnnRMcQCDsVJ75yed+9ze",
+	"l6P7woLmyTy135bMjWXu+QBlFWcmg0gMRcQSFTVY6T63wuYxMC5jdl/JkfslJIshEilPWAwjDWBWg3CG",
+	"ChOHuPbq1XzzM9S7k3k6cMSXyFHHHCeAMT/JURho+E0uEPEbnxNUbl5EvrAJBBszKOvAqZN884yWqlx2",
+	"7PI2PWdwmGkwBmIUHrwC1UGGIi3lXyjNolxrkNGE5VJY3AEc8jRDwG7dWr11I2zQnsoHCXQhqpxjHpYP",
+	"WALWQmMZ0j/csBiGQkKMwmxz+0Hv+rWrN5uLB1uPPg4aiwujyjHzVNhEscdKA6gnHSh9MByC7hDGoFPD",
+	"FL6EmFnlRHwiUJ2Q1AVGXyLV1tK3fSxu+CIRMdQqPU29twmiEhxtULfFCNFHsIbMafqh0uxnbKxybbrk",
+	"Q6YR3o2l1GwYWHVmOA/4JFNCzqiHM03RltFdEuZhwt0uZrghjpHcO5F+HkG/pLAuBdaZNyp5SruoKf5T",
+	"1Q1JnsVnhH4h2jZlltuz4e6NN9gm20dS/CYHxlMlR8RPuQH9D4ZlCJ4JwiDlh/dBjuw42Li6vn4at9Mi",
+	"DSC7eL20ludguac12b5s65M77ObP1m8yh9yQeNsLzgXG+ir7WHMZjZmSJNLCvozBcpGggcnZOE+57Gng",
+	"MR8kwFIwho+cHkv5BE1NOYJ5rVVaMzOsbt0kPBoLCfWsZBzR6ht9+Y+VEY/4AWNZjw1UPAlZxu0Yzdbf",
+	"5KAnLOOap2BBG8Y1KrFIaQ2RxRma14KwfSlgPZaefLXACUga7fq3of8JhxkeF+u532gyM6ksG9DhxyEr",
+	"AeZMwgFTEkiQtT6uJz/QSo52kWqqCdFcFsbkTmRzqewYlyE5jB9apXZTLie7lXxiPaZVboGVFvoi6V6t",
+	"K3BRyfe5SAj3vbmhePCxOpCIHy7NAakQlVtUtZGSVvPIspUb69euEExooEtld4cql3FY/3YXClyjeabI",
+	"ZXGekBTA5xpwVWc7N2aZfdyejHisOdw9gENhrMEBpfVXDSEQYkgzZVGV7u7BZFeDO7XmcyF3M61GGoxx",
+	"UFvQkie7znzvMvyJV9ryjxDK/IF3UMUpJm85z/WG1GjcJLy1Nctbn+7sPKyuX3BoWzbIxzxmWw6MrsVL",
+	"Q789Y67lhpcUG/18ff2DCDmU/oLW7M2BLWI/1caht+WWKiSETnx0icCtmi7mpf95tGMsTMZtNN7tMrMf",
+	"lxdvujALw/wKJP7weazxLsIMcB2NEfuaLM/lln4DC+p0bc6N3V185/TircMau0PGpi3NMQ3EWnHtg1Ba",
+	"jITkCYk3YYi6lYX4ze20pmh4k7tiNU9QH6+/O/pf9MqJlNPukecwHhsuizZuN+/WxHQgkgQVR0lReE0M",
+	"wtMP9uw21Iw1OwOStKBTiAW3wIxVmUEzYl8YYZ2GjEEH4VszgbdQd3W405bgjG2rsuUOqBz5jtBQATa7",
+	"+6bgox0S8F1SjqaYA/IhAo9KmDudH6K/gaN5RzzKxNDrQREzcqrZkJEFcSAMsIRbElsJcUKnj2GR32Du",
+	"ebnMOV0rOyKFREi4J22Xa+5Ez1SHngzubm3+y72t3U8ePPrsbqd6Eym0QD2RW7qV4Z1E5fE9hIzhgJYn",
+	"lQBuacSG65RHEWQWlkWNFlnHtcbPcSau5xqV09m+qV3FS0tt/4kG7j3/CyY9FyQQ7w4mXXpZsXJAdQxh",
+	"6d9Am7vpzUZ3tFb5aOyczbcfbrbOyn3VCcN57AmyCDrF/zZ4Vz4v7QZ3rqbaQteEIOMzgvA27Yqm4XDB",
+	"6n5G2IloL88Y7tGJvIFSexCXaFraNtD2je0JL162793euvNpEM6Kmwef7T58sL25s/ngsyAMtndub+3c",
+	"w+f3PrtL/79z+7M79+7fu3tR5sVPywJAgfeIdrRAEZwqikMGaWYnCDWFnYQ2tvRTLBLKnjBOPv/F4S0+",
+	"tKBpNZqugmBYP2OxAkPeAeciYcJegGp6o2DYY3cpvph7E22y7R5doEnrMNWSMsZApKHj3D/91e07bA8m",
+	"IVMyqc67DFYzq7wxXe6zy3rWSRsELZbBnQMq18JOtpHUHdqczwhDyvWvT8qZf/F4J5gN8Bb/XRwXr4oX",
+	"xWtW/FgcF99Pf198VxwXz4tn06fFi+L76behe/Pn4sfi5fTr4lnxung9/V3xF1a8Kr6f/mH6TXFc/MCK",
+	"l8V37BePf7nNViIlh2LEvjjYMw/RPVa8LL4vXtLvR1v3r6z2ZfHH4k/Tb4vvqkV+j1Oie4IVL5nJByH+",
+	"h02/nn5TvJw+LZ4VL6ZPp19PvyWP5q6IV9ljGGyraA8sfkGct61yHQErXhcv2PS3xQ/Fi+kfpk9xEpzg",
+	"OU40/X1f4qrF/+JWaZuvipdug9N/nz7FnUx/y6ZPK6TQt6+K/ytesOLH6VfFy+J18bL4oQFR8dw5/3rF",
+	"j8Wz6VfFM1r56fSr4gUpU2Ockw8dkiSU8BTdydTnPLY2cxkHQg7VPJ0Vf5w+LZ4XL6bfuJUZ7gJ3+SOt",
+	"+Lz4HqEtXiKY3xHEz+gYf2DTr4tjh4Pj6VfFMeEZDw7HFq9xe2wFxxTfFX+ms31ZvAhZcTx9ivsoXhfP",
+	"wpIyfjv9pl7luPhT8bo4biz9lytBHXe74+ye7SqOsw/auL1cXV1fXSfFnYHkmcBI1ur66gdBGKA3leh4",
+	"jZQ6/Zkp08F4W2BUsg+medswpQXs3I/IfErpWEhuwdBFw7u9nHVDSzC0TFreyb4sfY4u44DyEJKEHOnu",
+	"INlOk8eFm83wlEJv5TTs4YPtHeb3EfZlxo1hwhomyPVdGi0IpRuJgpRirSgGyUjcjBGRJD9o1sDd18DY",
+	"j1U8OSHx5WwJL+7C67JLWmk219bXL2wRt4GOnJoH7hSUrk8uLPFUorYRjUO6uX4iXBee9bNZevG9yz+X",
+	"exJd0dXFVuklHd4O+KuLYKmQv9ZKcDoKgxvr197ljru8703//EqX1/6K00l5mnK8QQdbpYtZlpymdOkm",
+	"CMLA8pEhd6sWWfAEP11zTIxwj5yubXPCfWHswzKS9UaEupThSEt1GIxz2NrOScqzCtpzHnMLe7jZTtmG",
+	"CjBEAvPRuRKPNCp4chRWArNLjrhNvR050giCdgqTqxe7UtdRuE3Glyok8FxQJFRh0/My/fX1W+9yBzue",
+	"uBhPMAg6YWNKbXF+PK8KhXFk16bVbb4P5cgOiqxZe+3LUmYeOY2egIV5Ur1Lz2tSbVHR9XlbwI2P3wDR",
+	"198loh1GpUKvUC5nGd9tpsn6nUzeKSF/DnYB1tbfPu9dlBj8KzuPn4M99TDqsD8l2gqalVPwxCVq1N7p",
+	"psvd6hzCBvyn3Z+fhIHPMJl3A5iKbcvoTWXYuv3tAWT4U+imVTxnbjq/x2WqiXdAqm6TPxk1cbnc8h4p",
+	"qi3w0PMUI1UuMctFoPwplFbWSbqskQdysrG61RzYLSXIc1CLiSrZoEbSW4ggoyB5+/ZzY/fLWNFNZDkP",
+	"r7tHtVzyl8mw/mwuyrZvxA5Mg9AaxNVBbmtfNn6hGbXmznuxn+QBuiV5jcVmFhMliw2gClutsgcyAlbT",
+	"U+hfNfJNrIFk2OGlKEtRKuDn6H3WYS5A2t4IJE4DMctd7uIeTFbZbabB6kntgCHnyh5MiFfxFo4Dci29",
+	"f8AqymKrXDL03WaZQmV7xPcTiDcYqtyPcCaXoLcHmYvNXbvuMntxZ8SYrkin5szNOiGr90uYtFi0kVV5",
+	"7caNDt9tp0nQPsk3NQzemhJtsfGJbFvR0fuiypqscNkKrQmLcEGamme5nKRKQ4j2wgwhUn4m5gw6oucs",
+	"FpRgj8mZZZKf0o0oVPlQGGYsJvwIycr0whk55Zh6Jsq5WFA5UVYrxJnSDhR6NKTpyWAZZu4OJvT/VVYm",
+	"t2kg9sRNu2/CdlxlzE1fcsl+3fsMDm3vTq6N0szx7EescrXu8yQnp2zkBrTlyVAkLlNXxswobfvSKjYC",
+	"SyOwTsgBNSfrcCc7XmyfT6d3CIgui77K7KMjLAN8wjAfAOtarYyb+WSeDhlyYmr7yYAMYEgnsiQMVp0L",
+	"gq4pKYy/mwq5YMYF1TOnTMcPL2Y6pJ7WTDEMORVlBr1GIDOsjLnWw+aQJ0tjJBGpWLDotXXK9Bdpnvo8",
+	"/1RI/yvsKOabPfQ2V5UWsYZ9oXJDTLHo4OmLE0n9nZieSLXn89y2qnNbeOhMS23gpxIYVYmscsFgKi31",
+	"SDuptvcSTVsnCUOSgiEjymKueg03flEW76yp6z3/4QLL1fly60QkJtUBaUDURyjfXeJS837gUwzRgGwo",
+	"rWaSNGqAvrQzCtfnMLCrN1gqZI6relHXnP2jOpRhx9z6ZHrjgz2otvuyzDyuAlqkaSjmsSi0t+P06t+s",
+	"5aaEOJ/baaYCSVCpShVprcNPbAXVY+gKWmKm6AuesCol6coq+6QxvIoW96XPLgfTcrHNBcwUXqzGPBk2",
+	"jZ35kqSzJBUvzo/HvQ2b4eyOMsr5jJZlU91CN2eDN6xiH6yzmCNZ4MmvsgepcJTBJRNpmbl1pry4c+ZM",
+	"v1n682xOzYKAEhXJWK9UTlFfWOeC4Q4xQ5t1ztemNKCRfGIV5Slqis27G+xmdAs+/PDmrd7N69du9K6v",
+	"x9C7df36oAfrN4fR1eGtdQ5d9bnzlwmUeFEdB7vIuNspd0Fa2gneSw/Ts5WZir0rvmKOrZxURxfOFcJd",
+	"6Uul21pmpatw7EpHYsDKTFHYlSpVAMXvybkCbGW+su7K+xJLXPqSys5xR30/0yG8JJm5RTeyH/BPs1bn",
+	"MHbeojGfCnRvG1FHSW+IIQ08dZhVskz1zECXmaE+2RMzluXE91lpKKW+3KkSRF3ODceve/iSGTwJGYHv",
+	"bBA2kkkpgOEzdGYrEThWN+KLmFvupqyTatFiQYHqAXcVao1kKwLXsJTH4C+9aOtxMtMlxKt9uQWRkhJZ",
+	"3XeyMLZHq/ewnEkxDRGIfWhPJ6hdg0DFbA8AJFuprPOr6+vrfVmOLE2dKwjoGLi2A+CWRSolYU02prSI",
+	"BT1BtWgQmrgjdQu37A7pNPvu40apr6sVIIPPOEMz4pL8Qd41cdtzNi3EKtOr60LWzD88mwdiMy6JhDDk",
+	"MRpXdSWdZl/rIFrrVRfQjvLRoyfLKVVauueo5pRGSbMc7EjTf3qZqqmNoQu4WW07JrKNTHBPxydJmS/9",
+	"jedoYfzq50A+ro8nm3dPI97Nu1SF7W9YXsB1OLz9mn+1nm7nNVg6seGSKMjdaN8bH7u/uS/OraAB2Kfm",
+	"7lIEe2rAaxtk3OweRnKby6rhTLMrWaNP2QAilYJhZWVMVVzcl40ajarAi34yV85mPpqbmPpQOAvPgeJh",
+	"ENJYvDD15Z2OtmnsYCwSqEuxhPFV3BSJU8aiHh0C1PdOl2mf032+1SwtU4mIJkzJqD0fnQIaWzTGCBpM",
+	"kOE7PRBxDNJrXV825A2E1rrN5GfXDq7S3m6nJ3SAg0Me2cR3WRsCkH29sOubaNQ0tuRbXfCOkEnFEiVH",
+	"oFlKoUuzKET5N2/LaYr/HUrzcD6rnxvlLCA68SYpLDBxfJHnSWCkQlZdb5ZRKdcuTKW0OjR2uRfofe1R",
+	"ugTNUva09Hh8T9XKpcRsS+eyF5Qho/JKlK6lFrmkiO1pl8xam1pf9r74wulkrtVcOm1hZhp2Vok6TiU6",
+	"jyHdQyWKSbyH1gXpq32JBfbC37KoZo5nGdUTh4yzBK/IbiJhmCgdZejZt6ZsKSBpke7rVlnG/1OyWZcL",
+	"ebX6F7zDqoW/Louy1fC1poUlOOFgsdOlKjV0Vk2rmrf0gES+R01lqEDookJNzwcOznIzRoMAdZxzIbTM",
+	"mlV2v+IBl5oA+7UnIFbN8l1nX+DHfbnS0Rn3CjEZfuAWdeBT+zXNMiFHxkNw43rpxKAVo0QZwAKsBJh3",
+	"tBDjO7Pww/Vu1nNbfB9dHZcoC65eoHe+PoROfj8QLmMUL0IVRYclHTuB3JziJyANtvMBjh1QLejSLhLf",
+	"qO3k3N7H5aB3Idz9YucS6yFzteumEgbuenLuNL35wH6JsKZbuYFa//qEUP89kkK1mdCcCGUmFsi6/oO5",
+	"Thg37aZftfO5L1ea2I7wBU1oiOCurJYN5wyLuNYT9uueR21vMw4bv8oe3WFf1g9RxRrL04yEZP0cm8Vy",
+	"m2vYYGbMr9348J9dZ7oxHDLsD9Db/vT2tRsf4qb6gXtly6noJ6y6p3h5dA/6QV9WF0yPP3+O/gt2W06Y",
+	"VLJ37fDQd0R0nc+sFqVpCYfuLAVP2IBHe2o47MuVymHNKM0JJQJGehW7StfMkF1dZ2UncqzVv9fQP87h",
+	"0eg1QWeG+fep2ncn5Iy4KvhQEl3VIjvsS1N56LVhEly3OvRT+G80kG0rlFyUM1G3UzhvqkBHVw9zalsP",
+	"Cn37nYSMJ0mpnMXQtdtoNiXpbPJBlu7iDk91XwrfFaINztjazLBHW/dd+5kMjy9iY2XsKruvVIaHHLJM",
+	"i32yPxIh93pY2ZAQxVLfrb5EovFf+goIL/yriKTLv5Qx4yMuJFPSmwl+55MgPL1bRbOxGO7lSUew/W2W",
+	"iFYic2GRqItlOabyrENi0d1KEAeX6udFSedMRGkvJFBQ6UIuGTXmBBlTWLlSjlDGquYld1Mvrn1ZN7Jf",
+	"oo6z5tXTy+TqiS/4xvX+V42WWuCUulE/LGT+Xycomba8cqdcY16crw0601Gv1VOdaBrdrYe9wzMPz1xR",
+	"dcZ/v+Ecibg3mom4N05NxH0njoLStHkDH8GlFmBRzSSh/KfDuHQgLFGj0vCtuFjCARjrXA7d3NroA0Uc",
+	"1uwA9fmToyfVR3Mthf7n5OZB2Mfo5UyroPZlOJi/Sxf/WRwXL6iX0Cv8+nXxjBXPsRdS8cP0287ip65Z",
+	"/qs4nv4bAfe6eOH7Tb1g1FiJujktBCvzTTvmZvyP4rhqBPUKWydhy6M/TX83fYqdi1yHIwf3cfHKwU1K",
+	"snhW/BnBx8ZXzHV4mj5FqKiZ0vM5MRYcPTn6/wEAqL96jrxqAAA=",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"final-project/internal/httpadapter"
+	"final-project/internal/pubsub"
 	"final-project/models"
 	kfk "final-project/pkg/kafka"
 	"github.com/go-chi/chi/v5"
@@ -19,12 +20,14 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"shared/auth"
 
 	"go.uber.org/zap"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -33,8 +36,9 @@ type app struct {
 	//tracer opentracing.Tracer
 	httpAdapter   httpadapter.Adapter
 	client        *mongo.Client
-	fromTripTopic *kafka.Conn
-	toDriverTopic *kafka.Conn
+	fromTripTopic *kafka.Reader
+	toDriverTopic *kafka.Writer
+	broker        *pubsub.Broker
 }
 
 const configPath = "./config/config.json"
@@ -59,6 +63,9 @@ func (a *app) Serve(ctx context.Context) error {
 
 	a.DisconnectMongo()
 	a.Shutdown()
+	if err := a.broker.Close(); err != nil {
+		logger.Error("pub/sub broker close error", zap.Error(err))
+	}
 	return nil
 }
 
@@ -172,17 +179,40 @@ func New(ctx context.Context) (App, error) {
 	logger.Info("Prometheus initialized")
 
 	// Подключение к Kafka
-	connTrip, err := kfk.ConnectKafka(ctx, config.KafkaAddress, "trip-client-topic", 0)
+	err = kfk.CreateTopics(config.KafkaAddress, config.KafkaPartitions,
+		httpadapter.FromTripTopic, httpadapter.ToTripTopic, httpadapter.DeadLetterTopic)
+	if err != nil {
+		logger.Error("Kafka topics create error. %v", zap.Error(err))
+		log.Fatal(err)
+	}
+	connTrip := kfk.NewReader(config.KafkaAddress, httpadapter.FromTripTopic, httpadapter.ConsumerGroup)
+	connDriver := kfk.NewWriter(config.KafkaAddress, httpadapter.ToTripTopic)
+	connDLQ := kfk.NewWriter(config.KafkaAddress, httpadapter.DeadLetterTopic)
+
+	// Reader-ы без группы: события получают все реплики, держащие WebSocket-ы
+	tailReaders, err := kfk.NewTailReaders(config.KafkaAddress, httpadapter.FromTripTopic)
+	if err != nil {
+		logger.Error("Kafka readers create error", zap.Error(err))
+		log.Fatal(err)
+	}
+	broker := pubsub.NewBroker(tailReaders)
+
+	// Ключи проверки bearer токенов
+	logger.Info("Loading JWKS")
+	keys, err := auth.NewKeySet(ctx, config.JWKSPath, config.JWKSURL)
 	if err != nil {
-		logger.Error("Kafka connect error. %v", zap.Error(err))
+		logger.Error("JWKS load error", zap.Error(err))
 		log.Fatal(err)
 	}
+	authenticator := auth.NewAuthenticator(keys, config.JWTIssuer, config.JWTAudience)
 
-	connDriver, err := kfk.ConnectKafka(ctx, config.KafkaAddress, "driver-client-trip-topic", 0)
+	// Сервисный токен для перезапроса офферов броней
+	serviceToken, err := os.ReadFile(config.ServiceTokenPath)
 	if err != nil {
-		logger.Error("Kafka connect error. %v", zap.Error(err))
+		logger.Error("Service token read error", zap.Error(err))
 		log.Fatal(err)
 	}
+	config.ServiceToken = strings.TrimSpace(string(serviceToken))
 
 	// Инициализация Jaeger
 	logger.Info("Initializing Jaeger")
@@ -198,10 +228,11 @@ func New(ctx context.Context) (App, error) {
 	logger.Info("Tracer created")
 
 	a := &app{
-		httpAdapter:   httpadapter.New(ctx, config, tracer, client, connTrip, connDriver, requestsTotal, responseTime),
+		httpAdapter:   httpadapter.New(ctx, config, tracer, client, connTrip, connDriver, connDLQ, broker, authenticator, requestsTotal, responseTime),
 		client:        client,
 		fromTripTopic: connTrip,
 		toDriverTopic: connDriver,
+		broker:        broker,
 	}
 
 	return a, nil
@@ -3,18 +3,32 @@ package httpadapter
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"final-project/api"
+	"final-project/internal/idempotency"
+	"final-project/internal/outbox"
+	"final-project/internal/places"
+	"final-project/internal/pubsub"
+	"final-project/internal/reservation"
+	"final-project/internal/webhook"
 	"final-project/models"
 	kfk "final-project/pkg/kafka"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/juju/zaputil/zapctx"
+	openapi_types "github.com/oapi-codegen/runtime/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"shared/auth"
+	"shared/cancellation"
+	"shared/problem"
 
 	"go.uber.org/zap"
 	"io"
@@ -22,19 +36,83 @@ import (
 	"time"
 )
 
+// Топики Kafka
+const (
+	FromTripTopic   = "trip-client-topic"
+	ToTripTopic     = "driver-client-trip-topic"
+	DeadLetterTopic = "client-dlq-topic"
+)
+
+// ConsumerGroup группа, в которой реплики client делят партиции топика событий
+const ConsumerGroup = "client"
+
+// Повторы обработки при временных ошибках
+const (
+	maxAttempts = 3
+	retryDelay  = time.Second
+)
+
+// Этапы обработки, на которых сообщение может попасть в DLQ
+const (
+	stageUnmarshal   = "unmarshal"
+	stageContentType = "content_type"
+	stageData        = "data_unmarshal"
+	stageUnknownType = "unknown_type"
+	stageDBWrite     = "db_write"
+)
+
+// knownEvents события поездки, которые понимает сервис
+var knownEvents = map[string]bool{
+	"trip.event.created":     true,
+	"trip.event.accepted":    true,
+	"trip.event.on_position": true,
+	"trip.event.started":     true,
+	"trip.event.ended":       true,
+	"trip.event.canceled":    true,
+	"trip.event.rejected":    true,
+}
+
+// failure ошибка обработки сообщения с этапом, на котором она произошла
+type failure struct {
+	stage     string
+	err       error
+	retryable bool
+}
+
+// cancelResponse ответ POST /trips/{trip_id}/cancel. Fee - штраф по статусу из проекции, отсутствует,
+// если отмена бесплатна. trip спишет ровно его или отклонит отмену, если статус успел измениться
+type cancelResponse struct {
+	TripID string        `json:"trip_id"`
+	Status string        `json:"status"`
+	Fee    *models.Price `json:"fee,omitempty"`
+}
+
 type adapter struct {
 	config        *models.Config
 	mongoClient   *mongo.Client
 	mongoColl     *mongo.Collection
+	historyColl   *mongo.Collection
 	server        *http.Server
-	connTrip      *kafka.Conn
-	connDriver    *kafka.Conn
+	connTrip      *kafka.Reader
+	connDriver    *kafka.Writer
+	connDLQ       *kafka.Writer
+	broker        *pubsub.Broker
+	webhooks      *webhook.Store
+	dispatcher    *webhook.Dispatcher
+	outbox        *outbox.Relay
+	reservations  *reservation.Store
+	places        *places.Store
+	cancellation  *cancellation.Policy
+	scheduler     *reservation.Scheduler
+	idempotency   *idempotency.Store
+	auth          *auth.Authenticator
+	upgrader      *websocket.Upgrader
 	RequestsTotal *prometheus.CounterVec
 	ResponseTime  *prometheus.GaugeVec
 	Tracer        trace.Tracer
 }
 
-func (a *adapter) ListTrips(w http.ResponseWriter, r *http.Request) {
+func (a *adapter) ListTrips(w http.ResponseWriter, r *http.Request, params api.ListTripsParams) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("ListTrips").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -44,49 +122,60 @@ func (a *adapter) ListTrips(w http.ResponseWriter, r *http.Request) {
 	ctx, span := a.Tracer.Start(r.Context(), "getOffer")
 	defer span.End()
 
-	userID := r.Header.Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id in header", http.StatusBadRequest)
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Фильтр, сортировка и страница
+	query, err := newTripQuery(&params)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Incorrect query")
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Query MongoDB for trips based on user_id
-	cursor, err := a.mongoColl.Find(ctx, bson.M{"user_id": userID})
+	cursor, err := a.mongoColl.Find(ctx, query.filter(userID), query.options())
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Finding element error")
-		http.Error(w, "Finding element error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
 		return
 	}
 	defer cursor.Close(ctx)
 
 	// Decode MongoDB documents into Trip structs
-	var trips []models.OmitUserTrip
+	trips := []models.OmitUserTrip{}
 	for cursor.Next(ctx) {
 		var trip models.Trip
 		if err := cursor.Decode(&trip); err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Decoding error")
-			http.Error(w, "Decoding error", http.StatusInternalServerError)
+			problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Decoding error")
 			return
 		}
-		respTrip := models.OmitUserTrip{
-			ID:      trip.ID,
-			OfferID: trip.OfferID,
-			From:    trip.From,
-			To:      trip.To,
-			Price:   trip.Price,
-			Status:  trip.Status,
-		}
+		respTrip := omitUser(&trip)
 		trips = append(trips, respTrip)
 	}
 
+	// Лишняя поездка означает, что есть следующая страница
+	if int64(len(trips)) > query.Limit {
+		trips = trips[:query.Limit]
+		last := trips[len(trips)-1]
+		next := tripCursor{CreatedAt: last.CreatedAt, ID: last.ID, Sort: query.Sort}
+		w.Header().Set("X-Next-Cursor", next.encode())
+	}
+
 	// Convert trips to JSON
 	tripsJSON, err := json.Marshal(trips)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Marshaling error")
-		http.Error(w, "Marshaling error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Marshaling error")
 		return
 	}
 
@@ -97,14 +186,14 @@ func (a *adapter) ListTrips(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Writing response error")
-		http.Error(w, "Writing response error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Writing response error")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
-func (a *adapter) CreateTrip(w http.ResponseWriter, r *http.Request) {
+func (a *adapter) CreateTrip(w http.ResponseWriter, r *http.Request, _ api.CreateTripParams) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("CreateTrip").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -114,10 +203,10 @@ func (a *adapter) CreateTrip(w http.ResponseWriter, r *http.Request) {
 	ctx, span := a.Tracer.Start(r.Context(), "getOffer")
 	defer span.End()
 
-	// Retrieve user_id from header
-	userID := r.Header.Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id in header", http.StatusBadRequest)
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -126,46 +215,72 @@ func (a *adapter) CreateTrip(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Decoding error")
-		http.Error(w, "Decoding error", http.StatusBadRequest)
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Decoding error")
 		return
 	}
 
+	// Маршрут вместо offer_id: оффер запрашивается у offering от имени пользователя
+	if incomingOffer.OfferID == "" && incomingOffer.Route != nil {
+		offer, err := a.requestOffer(ctx, auth.BearerToken(r), userID, incomingOffer.Route)
+		if err != nil {
+			writeOfferError(w, span, err)
+			return
+		}
+		incomingOffer.OfferID = offer.ID
+	}
+
 	resp, err := http.Get(a.config.OfferingAddress + "/" + incomingOffer.OfferID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed connecting to offering service")
-		http.Error(w, "Failed connecting to offering service", http.StatusInternalServerError)
+		problem.Write(w, http.StatusBadGateway, problem.CodeOfferingUnavailable, "Failed connecting to offering service")
 		return
 	}
 	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Error reading response body")
-		http.Error(w, "Error reading response body", http.StatusBadRequest)
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, http.StatusBadGateway, problem.CodeOfferingUnavailable, "Error reading response body")
 		return
 	}
 	defer resp.Body.Close()
 
+	// Ошибка оффера (offer_invalid, offer_expired) передается клиенту с кодом offering
+	if resp.StatusCode != http.StatusOK {
+		rejected := problem.Parse(resp, bytes)
+		if rejected != nil && rejected.Status < http.StatusInternalServerError {
+			rejected.Write(w)
+			return
+		}
+		span.SetStatus(codes.Error, "Offering service error")
+		problem.Write(w, http.StatusBadGateway, problem.CodeOfferingUnavailable, "Offering service responded "+resp.Status)
+		return
+	}
+
 	var decodedOrder models.OrderOffering
 	err = json.Unmarshal(bytes, &decodedOrder)
 	fmt.Println(decodedOrder)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Error decoding JSON request")
-		http.Error(w, "Error decoding JSON request", http.StatusBadRequest)
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, http.StatusBadGateway, problem.CodeOfferingUnavailable, "Error decoding offering response")
 		return
 	}
 	if userID != decodedOrder.ClientID {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Wrong user_id")
-		http.Error(w, "Wrong user_id", http.StatusBadRequest)
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, http.StatusBadRequest, problem.CodeOfferWrongUser, "Wrong user_id")
+		return
+	}
+
+	// Поездка на время: сохраняется бронь, команду создания отправит планировщик
+	if incomingOffer.ScheduledAt != nil {
+		a.scheduleTrip(ctx, w, span, userID, &incomingOffer, &decodedOrder)
 		return
 	}
 
 	newID := uuid.New().String()
+	createdAt := time.Now().UTC()
 	// Insert the offer_id into MongoDB
 	newTrip := models.Trip{
 		ID:      newID,
@@ -179,67 +294,47 @@ func (a *adapter) CreateTrip(w http.ResponseWriter, r *http.Request) {
 			Lat: decodedOrder.To.Lat,
 			Lng: decodedOrder.To.Lng,
 		},
+		Waypoints: decodedOrder.Waypoints,
 		Price: models.Price{
 			Amount:   decodedOrder.Price.Amount,
 			Currency: decodedOrder.Price.Currency,
 		},
-		Status: "DRIVER_SEARCH",
-	}
-
-	//make kafka payload
-	kafkaPayload := models.Request{
-		Id:              newID,
-		Source:          "/client",
-		Type:            "trip.command.create",
-		DataContentType: "application/json",
-		Time:            time.Now().UTC(),
-		Data:            nil,
-	}
-
-	createTripData := models.CommandCreateData{
-		OfferId: incomingOffer.OfferID,
+		Status:    "DRIVER_SEARCH",
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
 	}
 
-	kafkaPayload.Data, err = json.Marshal(createTripData)
+	// Поездка и команда сохраняются одной записью в outbox: после нее они обязательно
+	// попадут в Mongo и Kafka, до нее - ни одна из них
+	message, err := outbox.NewTripMessage(&newTrip)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Kafka payload generating error")
-		http.Error(w, "Kafka payload generating error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Kafka payload generating error")
 		return
 	}
-
-	kafkaPayloadJSON, err := json.Marshal(kafkaPayload)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Error encoding Kafka payload")
-		http.Error(w, "Error encoding Kafka payload", http.StatusInternalServerError)
-		return
-	}
-
-	err = kfk.SendToTopic(a.connDriver, kafkaPayloadJSON)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	err = a.outbox.Add(ctx, message)
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Error sending message to Kafka")
-		http.Error(w, "Error sending message to Kafka", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, "Insertion error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Insertion error")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	insertResult, err := a.mongoColl.InsertOne(ctx, newTrip)
+	// Обычно сообщение обрабатывается сразу, при ошибке его доотправит relay
+	err = a.outbox.Process(ctx, message)
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Insertion error")
-		http.Error(w, "Insertion error", http.StatusInternalServerError)
-		return
+		zapctx.Logger(ctx).Warn("Outbox message left for relay", zap.String("trip_id", newID), zap.Error(err))
 	}
 
-	// Return the inserted document ID
-	fmt.Fprintf(w, "Inserted document ID: %v", insertResult.InsertedID)
-	w.WriteHeader(http.StatusOK)
+	// Return the inserted trip ID
+	fmt.Fprintf(w, "Inserted document ID: %v", newID)
 }
 
-func (a *adapter) GetTripByID(w http.ResponseWriter, r *http.Request) {
+func (a *adapter) GetTripByID(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("GetTripByID").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -249,18 +344,14 @@ func (a *adapter) GetTripByID(w http.ResponseWriter, r *http.Request) {
 	ctx, span := a.Tracer.Start(r.Context(), "getOffer")
 	defer span.End()
 
-	// Retrieve user_id from header
-	userID := r.Header.Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id in header", http.StatusBadRequest)
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
-	tripID := chi.URLParam(r, "trip_id")
-	if tripID == "" {
-		http.Error(w, "Missing trip_id in URL parameters", http.StatusBadRequest)
-		return
-	}
+	tripID := tripId.String()
 
 	// Define a filter to find the document by the "id" key
 	filter := bson.M{"id": tripID, "user_id": userID}
@@ -272,31 +363,24 @@ func (a *adapter) GetTripByID(w http.ResponseWriter, r *http.Request) {
 		if err == mongo.ErrNoDocuments {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Trip not found")
-			http.Error(w, "Trip not found", http.StatusNotFound)
+			problem.Write(w, http.StatusNotFound, problem.CodeTripNotFound, "Trip not found")
 			return
 		}
 
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Internal server error")
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
 		return
 	}
 
-	respTrip := models.OmitUserTrip{
-		ID:      trip.ID,
-		OfferID: trip.OfferID,
-		From:    trip.From,
-		To:      trip.To,
-		Price:   trip.Price,
-		Status:  trip.Status,
-	}
+	respTrip := omitUser(&trip)
 
 	// Marshal the result to JSON and send it in the response
 	tripJSON, err := json.Marshal(respTrip)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Marshaling error")
-		http.Error(w, "Marshaling error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Marshaling error")
 		return
 	}
 
@@ -304,7 +388,47 @@ func (a *adapter) GetTripByID(w http.ResponseWriter, r *http.Request) {
 	w.Write(tripJSON)
 }
 
-func (a *adapter) CancelTrip(w http.ResponseWriter, r *http.Request) {
+// TripTimeline возвращает переходы поездки по статусам в порядке времени событий
+func (a *adapter) TripTimeline(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("TripTimeline").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("TripTimeline").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "TripTimeline")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	filter := bson.M{"id": tripId.String(), "user_id": userID}
+	var trip models.Trip
+	err := a.mongoColl.FindOne(ctx, filter, options.FindOne().SetProjection(bson.M{"timeline": 1})).Decode(&trip)
+	if err == mongo.ErrNoDocuments {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Trip not found")
+		problem.Write(w, http.StatusNotFound, problem.CodeTripNotFound, "Trip not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	if trip.Timeline == nil {
+		trip.Timeline = []models.TimelineEntry{}
+	}
+	writeJSON(w, span, http.StatusOK, trip.Timeline)
+}
+
+func (a *adapter) CancelTrip(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, params api.CancelTripParams) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("CancelTrip").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -314,56 +438,72 @@ func (a *adapter) CancelTrip(w http.ResponseWriter, r *http.Request) {
 	ctx, span := a.Tracer.Start(r.Context(), "getOffer")
 	defer span.End()
 
-	// Retrieve user_id from header
-	userID := r.Header.Get("user_id")
-	if userID == "" {
-		http.Error(w, "Missing user_id in header", http.StatusBadRequest)
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
-	tripID := chi.URLParam(r, "trip_id")
-	if tripID == "" {
-		http.Error(w, "Missing trip_id in URL parameters", http.StatusBadRequest)
+	tripID := tripId.String()
+
+	// reason обязателен по контракту, пустой отклоняет валидация запроса
+	reason := params.Reason
+
+	// Поездка должна принадлежать пользователю и еще не завершиться. Статус CANCELED
+	// проставит событие trip.event.canceled: trip может отклонить отмену
+	var trip models.Trip
+	err := a.mongoColl.FindOne(ctx, bson.M{"id": tripID, "user_id": userID}).Decode(&trip)
+	if err == mongo.ErrNoDocuments {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Trip not found or not authorized")
+		problem.Write(w, http.StatusNotFound, problem.CodeTripNotFound, "Trip not found or not authorized")
 		return
 	}
-
-	// Retrieve "reason" from query parameters
-	reason := r.URL.Query().Get("reason")
-	if reason == "" {
-		http.Error(w, "Missing reason in query parameters", http.StatusBadRequest)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
 		return
 	}
 
-	// Define a filter to find the document by the "id" key
-	filter := bson.M{"id": tripID, "user_id": userID}
-
-	// Define an update to set the "status" field to "CANCELED"
-	update := bson.M{
-		"$set": bson.M{
-			"status": "CANCELED",
-		},
+	// Оценка штрафа по политике отмены. После начала поездки отмена запрещена
+	fee, err := a.cancellation.Fee(trip.Status, trip.Price.Amount)
+	if errors.Is(err, cancellation.ErrForbidden) {
+		problem.Write(w, http.StatusConflict, problem.CodeTripNotCancelable, "Trip can not be canceled in status "+trip.Status)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
 	}
 
 	//make kafka payload
 	kafkaPayload := models.Request{
 		Id:              uuid.New().String(),
 		Source:          "/client",
-		Type:            "trip.command.create",
+		Type:            "trip.command.cancel",
 		DataContentType: "application/json",
 		Time:            time.Now().UTC(),
 		Data:            nil,
 	}
 
 	cancelTripData := models.CommandCancelData{
-		TripId: tripID,
-		Reason: reason,
+		TripId:     tripID,
+		Reason:     reason,
+		CanceledBy: canceledByClient,
+	}
+	if fee > 0 {
+		cancelTripData.Fee = models.Price{Amount: fee, Currency: trip.Price.Currency}
 	}
 
 	kafkaPayloadData, err := json.Marshal(cancelTripData)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Error generating Kafka payload")
-		http.Error(w, "Kafka payload generating error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Kafka payload generating error")
 		return
 	}
 
@@ -373,36 +513,24 @@ func (a *adapter) CancelTrip(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Error encoding Kafka payload")
-		http.Error(w, "Error encoding Kafka payload", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Error encoding Kafka payload")
 		return
 	}
 
-	err = kfk.SendToTopic(a.connDriver, kafkaPayloadJSON)
+	err = kfk.SendToTopic(ctx, a.connDriver, tripID, kafkaPayloadJSON)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Error sending message to Kafka")
-		http.Error(w, "Error sending message to Kafka", http.StatusInternalServerError)
-		return
-	}
-
-	// Update the document in the collection
-	updateResult, err := a.mongoColl.UpdateOne(ctx, filter, update)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Internal server error")
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Error sending message to Kafka")
 		return
 	}
 
-	if updateResult.ModifiedCount == 0 {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Trip not found or not authorized")
-		http.Error(w, "Trip not found or not authorized", http.StatusNotFound)
-		return
+	// Отмена принята к исполнению, результат придет событием
+	response := cancelResponse{TripID: tripID, Status: trip.Status}
+	if fee > 0 {
+		response.Fee = &cancelTripData.Fee
 	}
-
-	// Respond with a success message or the updated document ID
-	fmt.Fprintf(w, "Trip canceled successfully")
+	writeJSON(w, span, http.StatusAccepted, response)
 }
 
 func (a *adapter) Serve(ctx context.Context) error {
@@ -415,16 +543,59 @@ func (a *adapter) Serve(ctx context.Context) error {
 	//	Namespace: "hw4", Name: "testcounter", Help: "Testing endpoint request counter",
 	//})
 
-	apiRouter.Get("/trips", http.HandlerFunc(a.ListTrips))
-	apiRouter.Post("/trips", http.HandlerFunc(a.CreateTrip))
-	apiRouter.Get("/trips/{trip_id}", http.HandlerFunc(a.GetTripByID))
-	apiRouter.Post("/trips/{trip_id}/cancel", http.HandlerFunc(a.CancelTrip))
+	// Операции из api/client.yaml. Все они требуют bearer токен, user id берется из него
+	err := a.routes(apiRouter)
+	if err != nil {
+		return err
+	}
 	apiRouter.Get("/", func(w http.ResponseWriter, r *http.Request) { // testing endpoint
 		w.WriteHeader(http.StatusOK)
 	})
 
 	apiRouter.Mount(a.config.BasePath, apiRouter)
 
+	// Рассылка событий поездок подписчикам WebSocket
+	go a.broker.Run(ctx)
+
+	// Индексы поездок для GET /trips
+	err = a.ensureTripIndexes(ctx)
+	if err != nil {
+		logger.Error("trip indexes error", zap.Error(err))
+	}
+
+	// Ключи Idempotency-Key с TTL
+	err = a.idempotency.EnsureIndexes(ctx)
+	if err != nil {
+		logger.Error("idempotency indexes error", zap.Error(err))
+	}
+
+	// Доставка событий в webhook-и
+	err = a.webhooks.EnsureIndexes(ctx)
+	if err != nil {
+		logger.Error("webhook indexes error", zap.Error(err))
+	}
+	go a.dispatcher.Run(ctx)
+
+	// Доотправка команд из outbox
+	err = a.outbox.EnsureIndexes(ctx)
+	if err != nil {
+		logger.Error("outbox indexes error", zap.Error(err))
+	}
+	go a.outbox.Run(ctx)
+
+	// Создание поездок по броням
+	err = a.reservations.EnsureIndexes(ctx)
+	if err != nil {
+		logger.Error("reservation indexes error", zap.Error(err))
+	}
+	go a.scheduler.Run(ctx)
+
+	// Сохраненные места
+	err = a.places.EnsureIndexes(ctx)
+	if err != nil {
+		logger.Error("place indexes error", zap.Error(err))
+	}
+
 	go func() {
 		for {
 			select {
@@ -433,7 +604,6 @@ func (a *adapter) Serve(ctx context.Context) error {
 			default:
 				// Read and process Kafka messages
 				a.iteration(ctx)
-				time.Sleep(1 * time.Second)
 			}
 		}
 	}()
@@ -450,65 +620,175 @@ func (a *adapter) Shutdown(ctx context.Context) {
 func (a *adapter) iteration(ctx context.Context) {
 	logger := zapctx.Logger(ctx)
 	// Чтение из Kafka
-	bytes, err := kfk.ReadFromTopic(a.connTrip)
+	message, err := kfk.ReadFromTopic(ctx, a.connTrip)
 	if err != nil {
 		logger.Error("Kafka read error. %v", zap.Error(err))
 		return
 	}
+	bytes := message.Value
 	logger.Info("Message detected")
 
+	// Обработка с повторами при временных ошибках
+	var fail *failure
+	attempts := 0
+	for attempts < maxAttempts {
+		attempts++
+		fail = a.handle(ctx, bytes)
+		if fail == nil || !fail.retryable {
+			break
+		}
+		time.Sleep(time.Duration(attempts) * retryDelay)
+	}
+
+	// Необработанное сообщение уходит в DLQ
+	if fail != nil {
+		err = a.deadLetter(ctx, &message, fail, attempts)
+		if err != nil {
+			logger.Error("Dead letter write error, offset not committed", zap.Error(err))
+			return
+		}
+	}
+
+	// Сообщение обработано или сохранено в DLQ
+	err = kfk.CommitMessage(ctx, a.connTrip, message)
+	if err != nil {
+		logger.Error("Kafka commit error", zap.Error(err))
+	}
+}
+
+// handle применяет событие поездки к MongoDB, при ошибке возвращает этап, на котором она произошла
+func (a *adapter) handle(ctx context.Context, bytes []byte) *failure {
+	logger := zapctx.Logger(ctx)
+
 	// Десериализация запроса
 	var request models.Request
-	err = json.Unmarshal(bytes, &request)
+	err := json.Unmarshal(bytes, &request)
 	if err != nil {
 		logger.Error("Unmarshal error. %v", zap.Error(err))
-		return
+		return &failure{stage: stageUnmarshal, err: err}
 	}
 
 	// Проверка на тип Data
 	if request.DataContentType != "application/json" {
+		err = fmt.Errorf("unsupported datacontenttype %q", request.DataContentType)
 		logger.Error("Data type error. %v", zap.Error(err))
-		return
+		return &failure{stage: stageContentType, err: err}
 	}
+
+	// Проверка типа события
+	if !knownEvents[request.Type] {
+		err = fmt.Errorf("unknown event type %q", request.Type)
+		logger.Error("Unknown event. %v", zap.Error(err))
+		return &failure{stage: stageUnknownType, err: err}
+	}
+
 	var eventData models.EventData
 	err = json.Unmarshal(request.Data, &eventData)
 	if err != nil {
 		logger.Error("Unmarshal error. %v", zap.Error(err))
-		return
+		return &failure{stage: stageData, err: err}
 	}
-	filter := bson.M{"id": eventData.TripId}
-	update := bson.M{"$set": bson.M{"status": selectStatus(request.Type)}}
 
-	_, err = a.mongoColl.UpdateOne(ctx, filter, update)
+	// Проекция события на документ поездки. Документ нужен и для того, чтобы узнать владельца
+	// поездки для истории статусов и webhook-ов
+	trip, err := a.project(ctx, &request, &eventData)
+	if err == mongo.ErrNoDocuments {
+		logger.Info("Event for unknown trip skipped", zap.String("trip_id", eventData.TripId))
+		return nil
+	}
 	if err != nil {
 		logger.Error("MongoDB update error. %v", zap.Error(err))
-		return
+		return &failure{stage: stageDBWrite, err: err, retryable: true}
+	}
+
+	// События, опоздавшие относительно текущего статуса, проекцию не меняют и не попадают ни в историю,
+	// ни в webhook-и: получатель не должен видеть откат статуса. Повтор уже примененного события
+	// записывается снова: история и очередь доставок отбрасывают его по id события
+	status := selectStatus(request.Type)
+	if status != "" && trip.Status != status {
+		logger.Info("Stale event ignored", zap.String("trip_id", eventData.TripId),
+			zap.String("type", request.Type), zap.String("status", trip.Status))
+		return nil
+	}
+
+	if status != "" {
+		logger.Info("MongoDB updated")
+		err = a.recordStatusChange(ctx, trip.UserID, &models.StatusChange{
+			EventID: request.Id,
+			TripID:  eventData.TripId,
+			Type:    request.Type,
+			Status:  status,
+			Time:    request.Time,
+		})
+		if err != nil {
+			logger.Error("MongoDB history update error. %v", zap.Error(err))
+			return &failure{stage: stageDBWrite, err: err, retryable: true}
+		}
+	}
+
+	// Доставка события в webhook-и владельца поездки
+	err = a.webhooks.Enqueue(ctx, trip.UserID, request.Id, request.Type, bytes)
+	if err != nil {
+		logger.Error("MongoDB webhook enqueue error. %v", zap.Error(err))
+		return &failure{stage: stageDBWrite, err: err, retryable: true}
 	}
-	logger.Info("MongoDB updated")
+	return nil
 }
 
-func selectStatus(eventType string) string {
-	var newStatus string
-	switch eventType {
-	case "trip.event.accepted":
-		newStatus = "ACCEPTED"
-	case "trip.event.ended":
-		newStatus = "ENDED"
-	case "trip.event.started":
-		newStatus = "STARTED"
-	}
-	return newStatus
+// deadLetter отправляет необработанное сообщение в DLQ вместе с описанием ошибки
+func (a *adapter) deadLetter(ctx context.Context, message *kafka.Message, fail *failure, attempts int) error {
+	logger := zapctx.Logger(ctx)
+	a.RequestsTotal.WithLabelValues("dead_letter").Inc()
+
+	bytes, err := json.Marshal(models.DeadLetter{
+		Service:     "client",
+		SourceTopic: FromTripTopic,
+		Key:         string(message.Key),
+		Stage:       fail.stage,
+		Error:       fail.err.Error(),
+		Attempts:    attempts,
+		FailedAt:    time.Now().UTC(),
+		Payload:     message.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = kfk.SendToTopic(ctx, a.connDLQ, string(message.Key), bytes)
+	if err != nil {
+		return err
+	}
+
+	logger.Warn("Message sent to DLQ", zap.String("stage", fail.stage), zap.Int("attempts", attempts))
+	return nil
 }
 
-func New(ctx context.Context, config *models.Config, tracer trace.Tracer, client *mongo.Client, connTrip *kafka.Conn, connDriver *kafka.Conn,
-	requestsTotal *prometheus.CounterVec, responseTime *prometheus.GaugeVec) Adapter {
+func New(ctx context.Context, config *models.Config, tracer trace.Tracer, client *mongo.Client, connTrip *kafka.Reader, connDriver *kafka.Writer,
+	connDLQ *kafka.Writer, broker *pubsub.Broker, authenticator *auth.Authenticator, requestsTotal *prometheus.CounterVec, responseTime *prometheus.GaugeVec) Adapter {
+	webhooks := webhook.NewStore(client.Database(config.DatabaseName))
+	mongoColl := client.Database(config.DatabaseName).Collection(config.CollName)
+	relay := outbox.NewRelay(client.Database(config.DatabaseName), mongoColl, connDriver)
+	reservations := reservation.NewStore(client.Database(config.DatabaseName))
 	return &adapter{
-		config:      config,
-		Tracer:      tracer,
-		mongoClient: client,
-		mongoColl:   client.Database(config.DatabaseName).Collection(config.CollName),
-		connTrip:    connTrip,
-		connDriver:  connDriver,
+		config:       config,
+		Tracer:       tracer,
+		mongoClient:  client,
+		mongoColl:    mongoColl,
+		historyColl:  client.Database(config.DatabaseName).Collection(historyCollName),
+		connTrip:     connTrip,
+		connDriver:   connDriver,
+		connDLQ:      connDLQ,
+		broker:       broker,
+		webhooks:     webhooks,
+		dispatcher:   webhook.NewDispatcher(webhooks),
+		outbox:       relay,
+		reservations: reservations,
+		places:       places.NewStore(client.Database(config.DatabaseName)),
+		cancellation: cancellation.NewPolicy(config.CancellationPolicy),
+		scheduler:    reservation.NewScheduler(reservations, relay, config.OfferingAddress, config.ServiceToken),
+		idempotency:  idempotency.NewStore(client.Database(config.DatabaseName)),
+		auth:         authenticator,
+		upgrader:     newUpgrader(config.AllowedOrigins),
 		//tracer:      ctx.Value("tracer").(trace.Tracer),
 		RequestsTotal: requestsTotal,
 		ResponseTime:  responseTime,
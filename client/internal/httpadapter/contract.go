@@ -0,0 +1,97 @@
+package httpadapter
+
+import (
+	"final-project/api"
+	"final-project/internal/idempotency"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/go-chi/chi/v5"
+	middleware "github.com/oapi-codegen/nethttp-middleware"
+	"net/http"
+	"shared/problem"
+)
+
+// Адаптер реализует интерфейс, сгенерированный по api/client.yaml (go generate ./api).
+// Расхождение обработчиков с api.gen.go ломает сборку, а устаревший api.gen.go или маршрут вне
+// контракта ловит contract_test.go
+var _ api.ServerInterface = (*adapter)(nil)
+
+// routes регистрирует операции контракта. Пути и параметры берутся из api/client.yaml, поэтому
+// роутер не может разойтись с контрактом. Порядок обработки запроса: auth, валидация по
+// контракту, Idempotency-Key, обработчик
+func (a *adapter) routes(router chi.Router) error {
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return err
+	}
+	// Адрес сервиса задается конфигом, сверяются только пути
+	spec.Servers = nil
+
+	validate := middleware.OapiRequestValidatorWithOptions(spec, &middleware.Options{
+		Options: openapi3filter.Options{
+			// Токен уже проверен auth.Middleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+		ErrorHandler: func(w http.ResponseWriter, message string, statusCode int) {
+			problem.Write(w, statusCode, problem.CodeInvalidRequest, message)
+		},
+	})
+
+	api.HandlerWithOptions(a, api.ChiServerOptions{
+		BaseRouter: router,
+		// Последний middleware выполняется первым
+		Middlewares: []api.MiddlewareFunc{
+			a.idempotent(idempotentOperations(spec)),
+			validate,
+			a.authenticate,
+		},
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		},
+	})
+	return nil
+}
+
+// authenticate проверяет bearer токен у операций, для которых контракт требует bearerAuth
+func (a *adapter) authenticate(next http.Handler) http.Handler {
+	protected := a.auth.Middleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(api.BearerAuthScopes) == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+}
+
+// idempotent включает Idempotency-Key только для операций, где контракт объявляет этот заголовок
+func (a *adapter) idempotent(operations map[string]bool) api.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		once := a.idempotency.Middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !operations[r.Method+" "+chi.RouteContext(r.Context()).RoutePattern()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			once.ServeHTTP(w, r)
+		})
+	}
+}
+
+// idempotentOperations операции контракта с заголовком Idempotency-Key, ключ - "METHOD /path"
+func idempotentOperations(spec *openapi3.T) map[string]bool {
+	operations := map[string]bool{}
+	for path, item := range spec.Paths.Map() {
+		for method, operation := range item.Operations() {
+			parameters := append(openapi3.Parameters{}, item.Parameters...)
+			parameters = append(parameters, operation.Parameters...)
+			for _, parameter := range parameters {
+				if parameter.Value != nil && parameter.Value.In == openapi3.ParameterInHeader &&
+					http.CanonicalHeaderKey(parameter.Value.Name) == idempotency.HeaderKey {
+					operations[method+" "+path] = true
+				}
+			}
+		}
+	}
+	return operations
+}
@@ -0,0 +1,145 @@
+package httpadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"final-project/internal/places"
+	"final-project/models"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"time"
+)
+
+// offeringTimeout время ожидания ответа offering на запрос оффера
+const offeringTimeout = 10 * time.Second
+
+// offerRequest тело POST /offers в offering: маршрут уже в координатах
+type offerRequest struct {
+	From      models.Location   `json:"from"`
+	To        models.Location   `json:"to"`
+	Waypoints []models.Location `json:"waypoints,omitempty"`
+}
+
+// CreateOffer запрашивает оффер на маршрут, точки которого могут быть сохраненными местами.
+// Ответ как у offering
+func (a *adapter) CreateOffer(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("CreateOffer").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("CreateOffer").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "CreateOffer")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var route models.Route
+	err := json.NewDecoder(r.Body).Decode(&route)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Decoding error")
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Decoding error")
+		return
+	}
+
+	offer, err := a.requestOffer(ctx, auth.BearerToken(r), userID, &route)
+	if err != nil {
+		writeOfferError(w, span, err)
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, offer)
+}
+
+// requestOffer подставляет координаты сохраненных мест и запрашивает оффер у offering
+// от имени пользователя, с его токеном
+func (a *adapter) requestOffer(ctx context.Context, token string, userID string, route *models.Route) (*models.OrderOffering, error) {
+	var order offerRequest
+	var err error
+	order.From, err = a.places.Resolve(ctx, userID, route.From)
+	if err != nil {
+		return nil, err
+	}
+	order.To, err = a.places.Resolve(ctx, userID, route.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, stop := range route.Waypoints {
+		location, err := a.places.Resolve(ctx, userID, stop)
+		if err != nil {
+			return nil, err
+		}
+		order.Waypoints = append(order.Waypoints, location)
+	}
+
+	body, err := json.Marshal(order)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, offeringTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.OfferingAddress, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	payload, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		rejected := problem.Parse(response, payload)
+		if rejected == nil {
+			rejected = problem.New(http.StatusBadGateway, problem.CodeOfferingUnavailable,
+				"Offering service responded "+response.Status)
+		}
+		return nil, rejected
+	}
+
+	var offer models.OrderOffering
+	err = json.Unmarshal(payload, &offer)
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// writeOfferError отвечает на ошибку requestOffer. Отказ offering по маршруту, например
+// too_many_waypoints, передается клиенту с его кодом
+func writeOfferError(w http.ResponseWriter, span trace.Span, err error) {
+	if errors.Is(err, places.ErrPlaceNotFound) {
+		problem.Write(w, http.StatusBadRequest, problem.CodePlaceNotFound, "Place not found")
+		return
+	}
+
+	var rejected *problem.Problem
+	if errors.As(err, &rejected) && rejected.Status < http.StatusInternalServerError {
+		rejected.Write(w)
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "Failed connecting to offering service")
+	problem.Write(w, http.StatusBadGateway, problem.CodeOfferingUnavailable, "Failed connecting to offering service")
+}
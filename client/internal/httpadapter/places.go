@@ -0,0 +1,240 @@
+package httpadapter
+
+import (
+	"encoding/json"
+	"final-project/internal/places"
+	"final-project/models"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.opentelemetry.io/otel/codes"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"strings"
+	"time"
+)
+
+// maxPlaceNameLength ограничение длины названия места
+const maxPlaceNameLength = 100
+
+// placeRequest тело POST /places и PUT /places/{place_id}
+type placeRequest struct {
+	Name     string          `json:"name"`
+	Address  string          `json:"address"`
+	Location models.Location `json:"location"`
+}
+
+// validate проверяет название и координаты места
+func (p *placeRequest) validate() string {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len(p.Name) > maxPlaceNameLength {
+		return "Incorrect name"
+	}
+	if p.Location.Lat < -90 || p.Location.Lat > 90 || p.Location.Lng < -180 || p.Location.Lng > 180 {
+		return "Incorrect location"
+	}
+	return ""
+}
+
+// CreatePlace сохраняет место пользователя
+func (a *adapter) CreatePlace(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("CreatePlace").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("CreatePlace").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "CreatePlace")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body placeRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Decoding error")
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Decoding error")
+		return
+	}
+	if message := body.validate(); message != "" {
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, message)
+		return
+	}
+
+	place := places.Place{
+		UserID:   userID,
+		Name:     body.Name,
+		Address:  body.Address,
+		Location: body.Location,
+	}
+	err = a.places.Create(ctx, &place)
+	if err == places.ErrPlaceExists {
+		problem.Write(w, http.StatusConflict, problem.CodePlaceExists, "Place with this name already exists")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Insertion error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Insertion error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusCreated, place)
+}
+
+// ListPlaces возвращает места пользователя
+func (a *adapter) ListPlaces(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("ListPlaces").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("ListPlaces").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "ListPlaces")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	userPlaces, err := a.places.List(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Finding element error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, userPlaces)
+}
+
+// GetPlace возвращает место пользователя по id
+func (a *adapter) GetPlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("GetPlace").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("GetPlace").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "GetPlace")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	place, err := a.places.Get(ctx, userID, placeId.String())
+	if err == places.ErrPlaceNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodePlaceNotFound, "Place not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Finding element error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, place)
+}
+
+// UpdatePlace заменяет название, адрес и координаты места
+func (a *adapter) UpdatePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("UpdatePlace").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("UpdatePlace").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "UpdatePlace")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body placeRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Decoding error")
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Decoding error")
+		return
+	}
+	if message := body.validate(); message != "" {
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, message)
+		return
+	}
+
+	place := places.Place{
+		ID:       placeId.String(),
+		UserID:   userID,
+		Name:     body.Name,
+		Address:  body.Address,
+		Location: body.Location,
+	}
+	err = a.places.Update(ctx, &place)
+	if err == places.ErrPlaceNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodePlaceNotFound, "Place not found")
+		return
+	}
+	if err == places.ErrPlaceExists {
+		problem.Write(w, http.StatusConflict, problem.CodePlaceExists, "Place with this name already exists")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, place)
+}
+
+// DeletePlace удаляет место
+func (a *adapter) DeletePlace(w http.ResponseWriter, r *http.Request, placeId openapi_types.UUID) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("DeletePlace").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("DeletePlace").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "DeletePlace")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	err := a.places.Delete(ctx, userID, placeId.String())
+	if err == places.ErrPlaceNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodePlaceNotFound, "Place not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package httpadapter
+
+import (
+	"context"
+	"final-project/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Статусы поездки (enum status из контракта)
+const (
+	statusDriverSearch = "DRIVER_SEARCH"
+	statusDriverFound  = "DRIVER_FOUND"
+	statusOnPosition   = "ON_POSITION"
+	statusStarted      = "STARTED"
+	statusEnded        = "ENDED"
+	statusCanceled     = "CANCELED"
+)
+
+// canceledByClient инициатор отмены через API клиента
+const canceledByClient = "client"
+
+// statusRank порядок статусов. Событие применяется, только если двигает поездку вперед,
+// поэтому повторы и опоздавшие события не откатывают проекцию. ENDED и CANCELED - конечные
+var statusRank = map[string]int{
+	"":                 0,
+	statusDriverSearch: 1,
+	statusDriverFound:  2,
+	statusOnPosition:   3,
+	statusStarted:      4,
+	statusEnded:        5,
+	statusCanceled:     5,
+}
+
+// eventStatuses статус, в который переводит поездку событие. trip.event.rejected статус не меняет
+var eventStatuses = map[string]string{
+	"trip.event.created":     statusDriverSearch,
+	"trip.event.accepted":    statusDriverFound,
+	"trip.event.on_position": statusOnPosition,
+	"trip.event.started":     statusStarted,
+	"trip.event.ended":       statusEnded,
+	"trip.event.canceled":    statusCanceled,
+}
+
+// eventTimeFields поле документа со временем события
+var eventTimeFields = map[string]string{
+	"trip.event.accepted":    "accepted_at",
+	"trip.event.on_position": "arrived_at",
+	"trip.event.started":     "started_at",
+	"trip.event.ended":       "ended_at",
+	"trip.event.canceled":    "canceled_at",
+}
+
+// selectStatus возвращает статус поездки после события, пустую строку для событий без смены статуса
+func selectStatus(eventType string) string {
+	return eventStatuses[eventType]
+}
+
+// advances проверяет, что status двигает поездку вперед относительно current
+func advances(current string, status string) bool {
+	return status != "" && statusRank[status] > statusRank[current]
+}
+
+// statusesBefore статусы, из которых поездка может перейти в status
+func statusesBefore(status string) []string {
+	rank := statusRank[status]
+	var statuses []string
+	for candidate, candidateRank := range statusRank {
+		if candidateRank < rank {
+			statuses = append(statuses, candidate)
+		}
+	}
+	return statuses
+}
+
+// project применяет событие к документу поездки и возвращает документ после него.
+// Неизвестная поездка - mongo.ErrNoDocuments
+func (a *adapter) project(ctx context.Context, request *models.Request, data *models.EventData) (*models.Trip, error) {
+	var trip models.Trip
+	status := selectStatus(request.Type)
+	if status == "" {
+		err := a.mongoColl.FindOne(ctx, bson.M{"id": data.TripId}).Decode(&trip)
+		return &trip, err
+	}
+
+	// Переход попадает в timeline, даже если статус поездки уже дальше: шаг все равно был.
+	// Записи упорядочены по времени события, повтор события отбрасывается по event_id
+	entry := models.TimelineEntry{Status: status, Type: request.Type, EventID: request.Id, Time: request.Time}
+	_, err := a.mongoColl.UpdateOne(ctx,
+		bson.M{"id": data.TripId, "timeline.event_id": bson.M{"$ne": request.Id}},
+		bson.M{"$push": bson.M{"timeline": bson.M{"$each": bson.A{entry}, "$sort": bson.M{"time": 1}}}})
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"status": status, "updated_at": time.Now().UTC()}
+	if field, ok := eventTimeFields[request.Type]; ok {
+		set[field] = request.Time
+	}
+	switch request.Type {
+	case "trip.event.accepted":
+		if data.DriverId != "" {
+			set["driver_id"] = data.DriverId
+		}
+	case "trip.event.canceled":
+		if data.Reason != "" {
+			set["cancel_reason"] = data.Reason
+		}
+		if data.CanceledBy != "" {
+			set["canceled_by"] = data.CanceledBy
+		}
+		if data.Fee.Currency != "" {
+			set["cancel_fee"] = data.Fee
+		}
+	}
+
+	filter := bson.M{"id": data.TripId, "status": bson.M{"$in": statusesBefore(status)}}
+	err = a.mongoColl.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&trip)
+	if err != mongo.ErrNoDocuments {
+		return &trip, err
+	}
+
+	// Поездка уже дальше этого события. Водитель все равно запоминается, если принятие пришло
+	// после начала поездки
+	if request.Type == "trip.event.accepted" && data.DriverId != "" {
+		err = a.mongoColl.FindOneAndUpdate(ctx,
+			bson.M{"id": data.TripId, "driver_id": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"driver_id": data.DriverId, "accepted_at": request.Time}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&trip)
+		if err != mongo.ErrNoDocuments {
+			return &trip, err
+		}
+	}
+
+	err = a.mongoColl.FindOne(ctx, bson.M{"id": data.TripId}).Decode(&trip)
+	return &trip, err
+}
+
+// omitUser убирает из поездки владельца для ответа API
+func omitUser(trip *models.Trip) models.OmitUserTrip {
+	return models.OmitUserTrip{
+		ID:           trip.ID,
+		OfferID:      trip.OfferID,
+		From:         trip.From,
+		To:           trip.To,
+		Waypoints:    trip.Waypoints,
+		Price:        trip.Price,
+		Status:       trip.Status,
+		DriverID:     trip.DriverID,
+		CancelReason: trip.CancelReason,
+		CancelFee:    trip.CancelFee,
+		CanceledBy:   trip.CanceledBy,
+		AcceptedAt:   trip.AcceptedAt,
+		ArrivedAt:    trip.ArrivedAt,
+		StartedAt:    trip.StartedAt,
+		EndedAt:      trip.EndedAt,
+		CanceledAt:   trip.CanceledAt,
+		ScheduledAt:  trip.ScheduledAt,
+		CreatedAt:    trip.CreatedAt,
+		UpdatedAt:    trip.UpdatedAt,
+	}
+}
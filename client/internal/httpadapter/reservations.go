@@ -0,0 +1,132 @@
+package httpadapter
+
+import (
+	"context"
+	"final-project/api"
+	"final-project/internal/reservation"
+	"final-project/models"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"time"
+)
+
+// scheduleTrip сохраняет бронь поездки на offer.ScheduledAt. Оффер уже проверен в CreateTrip
+func (a *adapter) scheduleTrip(ctx context.Context, w http.ResponseWriter, span trace.Span, userID string,
+	offer *models.Offer, order *models.OrderOffering) {
+	scheduledAt := offer.ScheduledAt.UTC()
+	now := time.Now()
+	if scheduledAt.Before(now.Add(reservation.LeadTime)) {
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidScheduledAt, "scheduled_at must be at least "+
+			reservation.LeadTime.String()+" ahead, omit it for an immediate trip")
+		return
+	}
+	if scheduledAt.After(now.Add(reservation.MaxAhead)) {
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidScheduledAt, "scheduled_at is too far ahead")
+		return
+	}
+
+	// Срок оффера нужен планировщику: истекший к отправке оффер будет перезапрошен
+	expiresAt, err := reservation.OfferExpiry(offer.OfferID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Incorrect offer")
+		problem.Write(w, http.StatusBadRequest, problem.CodeOfferInvalid, "Incorrect offer")
+		return
+	}
+
+	booked := reservation.Reservation{
+		UserID:         userID,
+		OfferID:        offer.OfferID,
+		OfferExpiresAt: expiresAt,
+		From:           order.From,
+		To:             order.To,
+		Waypoints:      order.Waypoints,
+		Price:          order.Price,
+		ScheduledAt:    scheduledAt,
+	}
+	err = a.reservations.Create(ctx, &booked)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Insertion error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Insertion error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusCreated, booked)
+}
+
+// ListReservations возвращает брони пользователя, фильтр по status
+func (a *adapter) ListReservations(w http.ResponseWriter, r *http.Request, params api.ListReservationsParams) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("ListReservations").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("ListReservations").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "ListReservations")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// status проверен по контракту
+	var status string
+	if params.Status != nil {
+		status = string(*params.Status)
+	}
+
+	reservations, err := a.reservations.List(ctx, userID, status)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Finding element error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, reservations)
+}
+
+// CancelReservation отменяет бронь, по которой поездка еще не создана
+func (a *adapter) CancelReservation(w http.ResponseWriter, r *http.Request, reservationId openapi_types.UUID, _ api.CancelReservationParams) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("CancelReservation").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("CancelReservation").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "CancelReservation")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	canceled, err := a.reservations.Cancel(ctx, userID, reservationId.String())
+	if err == reservation.ErrReservationNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodeReservationNotFound, "Reservation not found")
+		return
+	}
+	if err == reservation.ErrNotCancelable {
+		problem.Write(w, http.StatusConflict, problem.CodeReservationNotCancelable, "Reservation is not scheduled anymore, cancel the trip if it was dispatched")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, canceled)
+}
@@ -0,0 +1,211 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/json"
+	"final-project/api"
+	"final-project/models"
+	"fmt"
+	"github.com/juju/zaputil/zapctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"time"
+)
+
+// historyCollName коллекция с историей смен статуса (models.StatusHistory)
+const historyCollName = "status_history"
+
+// historyLimit сколько последних смен статуса хранится на пользователя для продолжения по Last-Event-ID
+const historyLimit = 1000
+
+// Интервалы SSE потока
+const (
+	ssePollInterval      = time.Second
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetry             = 3 * time.Second
+)
+
+// recordStatusChange добавляет смену статуса в историю пользователя и присваивает ей следующий seq.
+// Повторная запись события с тем же EventID ничего не меняет
+func (a *adapter) recordStatusChange(ctx context.Context, userID string, change *models.StatusChange) error {
+	// seq и добавление в историю считаются одним обновлением, поэтому порядок событий совпадает с seq
+	filter := bson.M{"_id": userID, "events.event_id": bson.M{"$ne": change.EventID}}
+	update := bson.A{
+		bson.M{"$set": bson.M{"seq": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$seq", 0}}, 1}}}},
+		bson.M{"$set": bson.M{"events": bson.M{"$slice": bson.A{
+			bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$events", bson.A{}}},
+				bson.A{bson.M{
+					"seq":      "$seq",
+					"event_id": bson.M{"$literal": change.EventID},
+					"trip_id":  bson.M{"$literal": change.TripID},
+					"type":     bson.M{"$literal": change.Type},
+					"status":   bson.M{"$literal": change.Status},
+					"time":     change.Time,
+				}},
+			}},
+			-historyLimit,
+		}}}},
+	}
+
+	_, err := a.historyColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	// Документ пользователя уже содержит событие: фильтр не совпал и upsert уперся в _id
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// TripEvents отправляет смены статуса поездок пользователя как Server-Sent Events.
+// id события - seq из истории. Без Last-Event-ID поток начинается с текущего момента,
+// с ним продолжается с места разрыва
+func (a *adapter) TripEvents(w http.ResponseWriter, r *http.Request, params api.TripEventsParams) {
+	a.RequestsTotal.WithLabelValues("TripEvents").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "TripEvents")
+	defer span.End()
+	logger := zapctx.Logger(ctx)
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Last-Event-ID проверен по контракту. Новое подключение начинается с текущего seq,
+	// а не с хранимой истории
+	var lastSeq int64
+	if params.LastEventID != nil {
+		lastSeq = int64(*params.LastEventID)
+	} else {
+		var err error
+		lastSeq, err = a.currentSeq(ctx, userID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Internal server error")
+			problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			logger.Error("MongoDB history read error", zap.Error(err))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		span.SetStatus(codes.Error, "Streaming unsupported")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if err != nil {
+		return
+	}
+	flusher.Flush()
+
+	poll := time.NewTicker(ssePollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		// Новые смены статуса, включая пропущенные до переподключения
+		changes, err := a.statusChanges(ctx, userID, lastSeq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			span.RecordError(err)
+			logger.Error("MongoDB history read error", zap.Error(err))
+		}
+		for i := range changes {
+			err = writeEvent(w, &changes[i])
+			if err != nil {
+				return
+			}
+			lastSeq = changes[i].Seq
+		}
+		if len(changes) > 0 {
+			flusher.Flush()
+		}
+
+		select {
+		case <-poll.C:
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// currentSeq возвращает seq последней смены статуса пользователя, 0 - смен еще не было
+func (a *adapter) currentSeq(ctx context.Context, userID string) (int64, error) {
+	var history models.StatusHistory
+	err := a.historyColl.FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"seq": 1})).Decode(&history)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return history.Seq, err
+}
+
+// statusChanges возвращает смены статуса пользователя с seq больше lastSeq
+func (a *adapter) statusChanges(ctx context.Context, userID string, lastSeq int64) ([]models.StatusChange, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"_id": userID, "seq": bson.M{"$gt": lastSeq}}},
+		bson.M{"$project": bson.M{"seq": 1, "events": bson.M{"$filter": bson.M{
+			"input": "$events",
+			"cond":  bson.M{"$gt": bson.A{"$$this.seq", lastSeq}},
+		}}}},
+	}
+
+	cursor, err := a.historyColl.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		return nil, cursor.Err()
+	}
+	var history models.StatusHistory
+	err = cursor.Decode(&history)
+	if err != nil {
+		return nil, err
+	}
+
+	return history.Events, nil
+}
+
+// writeEvent пишет смену статуса в формате SSE
+func writeEvent(w http.ResponseWriter, change *models.StatusChange) error {
+	data, err := json.Marshal(TripUpdate{
+		TripId: change.TripID,
+		Event:  change.Type,
+		Status: change.Status,
+		Time:   change.Time,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Type, data)
+	return err
+}
@@ -0,0 +1,169 @@
+package httpadapter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"final-project/api"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// defaultTripsLimit размер страницы GET /trips, если limit не задан (default в контракте)
+const defaultTripsLimit = 20
+
+// Порядок сортировки по времени создания
+const (
+	sortNewest = string(api.MinusCreatedAt)
+	sortOldest = string(api.CreatedAt)
+)
+
+// tripQuery фильтр, сортировка и страница GET /trips
+type tripQuery struct {
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	PriceMin    *float64
+	PriceMax    *float64
+	Sort        string
+	Limit       int64
+	After       *tripCursor
+}
+
+// tripCursor позиция последней поездки страницы. Пара (created_at, id) однозначно задает порядок
+type tripCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Sort      string    `json:"s"`
+}
+
+// encode кодирует курсор в непрозрачную строку
+func (c *tripCursor) encode() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// newTripQuery строит запрос из параметров GET /trips. Форматы, sort и диапазон limit уже проверены
+// по контракту, здесь разбирается только курсор
+func newTripQuery(params *api.ListTripsParams) (*tripQuery, error) {
+	tripQuery := tripQuery{
+		PriceMin: params.PriceMin,
+		PriceMax: params.PriceMax,
+		Sort:     sortNewest,
+		Limit:    defaultTripsLimit,
+	}
+	if params.Status != nil {
+		tripQuery.Status = *params.Status
+	}
+	if params.CreatedFrom != nil {
+		tripQuery.CreatedFrom = *params.CreatedFrom
+	}
+	if params.CreatedTo != nil {
+		tripQuery.CreatedTo = *params.CreatedTo
+	}
+	if params.Sort != nil {
+		tripQuery.Sort = string(*params.Sort)
+	}
+	if params.Limit != nil {
+		tripQuery.Limit = int64(*params.Limit)
+	}
+	if params.Cursor != nil && *params.Cursor != "" {
+		bytes, err := base64.RawURLEncoding.DecodeString(*params.Cursor)
+		if err != nil {
+			return nil, errors.New("incorrect cursor")
+		}
+		var cursor tripCursor
+		err = json.Unmarshal(bytes, &cursor)
+		if err != nil || cursor.ID == "" {
+			return nil, errors.New("incorrect cursor")
+		}
+		// Курсор имеет смысл только при той же сортировке
+		if cursor.Sort != tripQuery.Sort {
+			return nil, errors.New("cursor was issued for sort " + cursor.Sort)
+		}
+		tripQuery.After = &cursor
+	}
+
+	return &tripQuery, nil
+}
+
+// filter строит фильтр MongoDB для поездок пользователя
+func (q *tripQuery) filter(userID string) bson.M {
+	filter := bson.M{"user_id": userID}
+	if q.Status != "" {
+		filter["status"] = q.Status
+	}
+
+	createdAt := bson.M{}
+	if !q.CreatedFrom.IsZero() {
+		createdAt["$gte"] = q.CreatedFrom
+	}
+	if !q.CreatedTo.IsZero() {
+		createdAt["$lt"] = q.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	price := bson.M{}
+	if q.PriceMin != nil {
+		price["$gte"] = *q.PriceMin
+	}
+	if q.PriceMax != nil {
+		price["$lte"] = *q.PriceMax
+	}
+	if len(price) > 0 {
+		filter["price.amount"] = price
+	}
+
+	// Поездки после курсора в порядке сортировки
+	if q.After != nil {
+		operator := "$lt"
+		if q.Sort == sortOldest {
+			operator = "$gt"
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{operator: q.After.CreatedAt}},
+			bson.M{"created_at": q.After.CreatedAt, "id": bson.M{operator: q.After.ID}},
+		}
+	}
+
+	return filter
+}
+
+// options сортировка и размер страницы. Читается на одну поездку больше, чтобы узнать, есть ли следующая страница
+func (q *tripQuery) options() *options.FindOptions {
+	direction := -1
+	if q.Sort == sortOldest {
+		direction = 1
+	}
+	// Timeline в списке не отдается, он есть в GET /trips/{trip_id}/timeline
+	return options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "id", Value: direction}}).
+		SetLimit(q.Limit + 1).
+		SetProjection(bson.M{"timeline": 0})
+}
+
+// ensureTripIndexes создает индексы коллекции поездок и заполняет created_at/updated_at
+// у поездок, созданных до появления этих полей, временем из ObjectID
+func (a *adapter) ensureTripIndexes(ctx context.Context) error {
+	_, err := a.mongoColl.UpdateMany(ctx, bson.M{"created_at": bson.M{"$exists": false}}, bson.A{
+		bson.M{"$set": bson.M{
+			"created_at": bson.M{"$toDate": "$_id"},
+			"updated_at": bson.M{"$toDate": "$_id"},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = a.mongoColl.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,81 @@
+package httpadapter
+
+import (
+	"final-project/api"
+	"testing"
+	"time"
+)
+
+func TestTripCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123000000, time.UTC)
+	for _, sort := range []string{sortNewest, sortOldest} {
+		cursor := &tripCursor{CreatedAt: createdAt, ID: "5f0c9f5e-8d3a-4a47-9d4e-3f1e1f6f2b10", Sort: sort}
+		encoded := cursor.encode()
+		params := &api.ListTripsParams{Cursor: &encoded}
+		if sort == sortOldest {
+			oldest := api.ListTripsParamsSort(sortOldest)
+			params.Sort = &oldest
+		}
+
+		query, err := newTripQuery(params)
+		if err != nil {
+			t.Fatalf("sort %v: %v", sort, err)
+		}
+		if query.After == nil {
+			t.Fatalf("sort %v: cursor was not decoded", sort)
+		}
+		if !query.After.CreatedAt.Equal(createdAt) || query.After.ID != cursor.ID || query.After.Sort != sort {
+			t.Fatalf("sort %v: decoded %+v, want %+v", sort, query.After, cursor)
+		}
+	}
+}
+
+func TestNewTripQueryCursor(t *testing.T) {
+	newest := (&tripCursor{CreatedAt: time.Now().UTC(), ID: "a", Sort: sortNewest}).encode()
+	oldest := (&tripCursor{CreatedAt: time.Now().UTC(), ID: "a", Sort: sortOldest}).encode()
+	noID := (&tripCursor{CreatedAt: time.Now().UTC(), Sort: sortNewest}).encode()
+	empty := ""
+	sortOldestParam := api.ListTripsParamsSort(sortOldest)
+	sortNewestParam := api.ListTripsParamsSort(sortNewest)
+
+	tests := []struct {
+		name    string
+		cursor  *string
+		sort    *api.ListTripsParamsSort
+		wantErr bool
+	}{
+		{"no cursor", nil, nil, false},
+		{"empty cursor", &empty, nil, false},
+		{"default sort", &newest, nil, false},
+		{"same sort", &oldest, &sortOldestParam, false},
+		{"cursor of newest with oldest", &newest, &sortOldestParam, true},
+		{"cursor of oldest with default sort", &oldest, nil, true},
+		{"cursor of oldest with newest", &oldest, &sortNewestParam, true},
+		{"cursor without id", &noID, nil, true},
+		{"not base64", ptr("%%%"), nil, true},
+		{"not json", ptr("bm90IGpzb24"), nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newTripQuery(&api.ListTripsParams{Cursor: test.cursor, Sort: test.sort})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("newTripQuery() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTripQueryDefaults(t *testing.T) {
+	query, err := newTripQuery(&api.ListTripsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Sort != sortNewest || query.Limit != defaultTripsLimit || query.After != nil {
+		t.Fatalf("defaults = %+v", query)
+	}
+}
+
+func ptr(value string) *string {
+	return &value
+}
@@ -0,0 +1,198 @@
+package httpadapter
+
+import (
+	"encoding/json"
+	"final-project/api"
+	"final-project/internal/webhook"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"time"
+)
+
+// defaultDeliveriesLimit размер страницы журнала доставок, если limit не задан (default в контракте)
+const defaultDeliveriesLimit = 50
+
+// webhookRequest тело POST /webhooks
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// CreateWebhook подписывает пользователя на события поездок. Секрет подписи возвращается только здесь
+func (a *adapter) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("CreateWebhook").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("CreateWebhook").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "CreateWebhook")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body webhookRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Decoding error")
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Decoding error")
+		return
+	}
+
+	// Доставка возможна только на публичный https адрес
+	err = webhook.ValidateURL(ctx, body.URL)
+	if err != nil {
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Incorrect url, expected https URL of a public host")
+		return
+	}
+	for _, event := range body.Events {
+		if !knownEvents[event] {
+			problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Unknown event "+event)
+			return
+		}
+	}
+
+	created, err := a.webhooks.Create(ctx, userID, body.URL, body.Events)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusCreated, created)
+}
+
+// ListWebhooks возвращает подписки пользователя
+func (a *adapter) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("ListWebhooks").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("ListWebhooks").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "ListWebhooks")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	webhooks, err := a.webhooks.List(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Finding element error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, webhooks)
+}
+
+// DeleteWebhook удаляет подписку
+func (a *adapter) DeleteWebhook(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("DeleteWebhook").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("DeleteWebhook").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "DeleteWebhook")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	err := a.webhooks.Delete(ctx, userID, webhookId.String())
+	if err == webhook.ErrWebhookNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodeWebhookNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries возвращает журнал доставок webhook-а с фильтром по status и limit
+func (a *adapter) ListDeliveries(w http.ResponseWriter, r *http.Request, webhookId openapi_types.UUID, params api.ListDeliveriesParams) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("ListDeliveries").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("ListDeliveries").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "ListDeliveries")
+	defer span.End()
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// status и диапазон limit проверены по контракту
+	var status string
+	if params.Status != nil {
+		status = string(*params.Status)
+	}
+	limit := int64(defaultDeliveriesLimit)
+	if params.Limit != nil {
+		limit = int64(*params.Limit)
+	}
+
+	deliveries, err := a.webhooks.Deliveries(ctx, userID, webhookId.String(), status, limit)
+	if err == webhook.ErrWebhookNotFound {
+		problem.Write(w, http.StatusNotFound, problem.CodeWebhookNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Finding element error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Finding element error")
+		return
+	}
+
+	writeJSON(w, span, http.StatusOK, deliveries)
+}
+
+// writeJSON сериализует value в ответ с кодом status
+func writeJSON(w http.ResponseWriter, span trace.Span, status int, value interface{}) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Marshaling error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Marshaling error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(bytes)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Writing response error")
+	}
+}
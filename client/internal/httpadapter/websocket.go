@@ -0,0 +1,184 @@
+package httpadapter
+
+import (
+	"final-project/api"
+	"final-project/models"
+	"github.com/gorilla/websocket"
+	"github.com/juju/zaputil/zapctx"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"shared/auth"
+	"shared/problem"
+	"strings"
+	"time"
+)
+
+// Таймауты WebSocket соединения
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingPeriod   = wsPongTimeout * 9 / 10
+)
+
+// newUpgrader создает upgrader WebSocket. Соединение авторизуется bearer токеном, как и остальные
+// методы API, а Origin браузера проверяется по allowedOrigins из конфига
+func newUpgrader(allowedOrigins []string) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return checkOrigin(r, allowedOrigins)
+		},
+	}
+}
+
+// checkOrigin пропускает запросы без Origin (не из браузера), с того же хоста и с разрешенных origin-ов
+func checkOrigin(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// TripUpdate сообщение, которое получает клиент по WebSocket.
+// Первое сообщение - текущее состояние поездки (event пустой), дальше - изменения
+type TripUpdate struct {
+	TripId string    `json:"trip_id"`
+	Event  string    `json:"event,omitempty"`
+	Status string    `json:"status,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// TripUpdates отправляет по WebSocket изменения статуса поездки пользователя
+func (a *adapter) TripUpdates(w http.ResponseWriter, r *http.Request, tripId openapi_types.UUID, _ api.TripUpdatesParams) {
+	a.RequestsTotal.WithLabelValues("TripUpdates").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "TripUpdates")
+	defer span.End()
+	logger := zapctx.Logger(ctx)
+
+	// Identity из bearer токена
+	userID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	tripID := tripId.String()
+
+	// Подписка до чтения состояния, чтобы не пропустить событие между ними
+	subscription := a.broker.Subscribe(tripID)
+	defer a.broker.Unsubscribe(subscription)
+
+	// Поездка должна принадлежать пользователю
+	var trip models.Trip
+	err := a.mongoColl.FindOne(ctx, bson.M{"id": tripID, "user_id": userID}).Decode(&trip)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Trip not found")
+			problem.Write(w, http.StatusNotFound, problem.CodeTripNotFound, "Trip not found")
+			return
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Internal server error")
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Upgrade сам отвечает клиенту при ошибке
+	conn, err := a.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "WebSocket upgrade error")
+		return
+	}
+	defer conn.Close()
+
+	// Чтение нужно для обработки pong и закрытия соединения клиентом
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		})
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// Текущее состояние
+	err = writeUpdate(conn, &TripUpdate{TripId: trip.ID, Status: trip.Status, Time: time.Now().UTC()})
+	if err != nil {
+		return
+	}
+
+	current := trip.Status
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.C:
+			if !ok {
+				// Broker отключил подписку
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscription closed"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			// Как и проекция, поток не откатывает статус: опоздавшие события и события без смены
+			// статуса (trip.event.rejected) клиенту не отправляются
+			status := selectStatus(event.Type)
+			if !advances(current, status) {
+				continue
+			}
+			current = status
+			err = writeUpdate(conn, &TripUpdate{
+				TripId: event.TripId,
+				Event:  event.Type,
+				Status: status,
+				Time:   event.Time,
+			})
+			if err != nil {
+				logger.Info("WebSocket write error", zap.Error(err))
+				return
+			}
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			if err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeUpdate отправляет сообщение клиенту
+func writeUpdate(conn *websocket.Conn, update *TripUpdate) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(update)
+}
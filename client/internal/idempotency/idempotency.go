@@ -0,0 +1,227 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/juju/zaputil/zapctx"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"shared/auth"
+	"shared/problem"
+	"time"
+)
+
+// Заголовки запроса и ответа
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// collName коллекция ключей рядом с коллекцией поездок
+const collName = "idempotency_keys"
+
+const (
+	// ttl сколько хранится ключ с ответом
+	ttl = 24 * time.Hour
+	// lockTimeout через сколько незавершенный запрос (например, упавшей реплики) можно выполнить заново
+	lockTimeout  = time.Minute
+	maxKeyLength = 255
+)
+
+// Состояния ключа
+const (
+	stateProcessing = "processing"
+	stateDone       = "done"
+)
+
+// record ключ с отпечатком запроса и сохраненным ответом
+type record struct {
+	UserID      string    `bson:"user_id"`
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"request_hash"`
+	State       string    `bson:"state"`
+	StatusCode  int       `bson:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	LockedAt    time.Time `bson:"locked_at"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// Store хранит ключи идемпотентности в MongoDB
+type Store struct {
+	coll *mongo.Collection
+}
+
+func NewStore(database *mongo.Database) *Store {
+	return &Store{
+		coll: database.Collection(collName),
+	}
+}
+
+// EnsureIndexes создает уникальный индекс ключей пользователя и TTL индекс
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds()))},
+	})
+	return err
+}
+
+// Middleware должен стоять после auth.Middleware: ключи хранятся в пределах пользователя.
+// Выполняет запрос с заголовком Idempotency-Key один раз. Повтор с тем же ключом и телом
+// получает сохраненный ответ, с другим телом или во время выполнения первого запроса - 409.
+// Ответы 5xx не сохраняются, такой запрос можно повторить с тем же ключом
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		userID, ok := auth.UserID(r.Context())
+		if key == "" || !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Idempotency-Key is too long")
+			return
+		}
+		ctx := r.Context()
+		logger := zapctx.Logger(ctx)
+
+		// Отпечаток запроса: метод, путь и тело
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Reading request error")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.New()
+		// access_token в query меняется между повторами и в отпечаток не входит
+		query := r.URL.Query()
+		query.Del("access_token")
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + query.Encode() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		stored, err := s.lock(ctx, userID, key, requestHash)
+		if err != nil {
+			logger.Error("Idempotency key lock error", zap.Error(err))
+			problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Internal server error")
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.RequestHash != requestHash:
+				problem.Write(w, http.StatusConflict, problem.CodeIdempotencyKeyReused, "Idempotency-Key was used with a different request")
+			case stored.State != stateDone:
+				problem.Write(w, http.StatusConflict, problem.CodeIdempotencyInProgress, "Request with this Idempotency-Key is in progress")
+			default:
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(HeaderReplayed, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+			}
+			return
+		}
+
+		// Запрос выполняется впервые, ответ запоминается
+		recorder := &recorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Запись ответа не должна зависеть от клиента, который мог уже отключиться
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if recorder.statusCode >= http.StatusInternalServerError {
+			_, err = s.coll.DeleteOne(ctx, bson.M{"user_id": userID, "key": key, "request_hash": requestHash})
+		} else {
+			_, err = s.coll.UpdateOne(ctx, bson.M{"user_id": userID, "key": key, "request_hash": requestHash},
+				bson.M{"$set": bson.M{
+					"state":        stateDone,
+					"status_code":  recorder.statusCode,
+					"content_type": recorder.Header().Get("Content-Type"),
+					"body":         recorder.body.Bytes(),
+				}})
+		}
+		if err != nil {
+			logger.Error("Idempotency key save error", zap.Error(err))
+		}
+	})
+}
+
+// lock занимает ключ для нового запроса и возвращает nil. Если ключ уже есть, возвращает его запись.
+// Ключ, занятый дольше lockTimeout без ответа, занимается заново
+func (s *Store) lock(ctx context.Context, userID string, key string, requestHash string) (*record, error) {
+	now := time.Now().UTC()
+	_, err := s.coll.InsertOne(ctx, record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		State:       stateProcessing,
+		LockedAt:    now,
+		CreatedAt:   now,
+	})
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// Перехват брошенного запроса с тем же отпечатком
+	result, err := s.coll.UpdateOne(ctx, bson.M{
+		"user_id":      userID,
+		"key":          key,
+		"request_hash": requestHash,
+		"state":        stateProcessing,
+		"locked_at":    bson.M{"$lt": now.Add(-lockTimeout)},
+	}, bson.M{"$set": bson.M{"locked_at": now}})
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 1 {
+		return nil, nil
+	}
+
+	var stored record
+	err = s.coll.FindOne(ctx, bson.M{"user_id": userID, "key": key}).Decode(&stored)
+	// Ключ мог истечь по TTL или быть удален после ответа 5xx между запросами
+	if err == mongo.ErrNoDocuments {
+		return s.lock(ctx, userID, key, requestHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// recorder пропускает ответ клиенту и запоминает его код и тело
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *recorder) Write(bytes []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(bytes)
+	return r.ResponseWriter.Write(bytes)
+}
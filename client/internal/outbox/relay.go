@@ -0,0 +1,208 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"final-project/models"
+	kfk "final-project/pkg/kafka"
+	"github.com/juju/zaputil/zapctx"
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"time"
+)
+
+// collName коллекция outbox рядом с коллекцией поездок
+const collName = "outbox"
+
+// Параметры relay
+const (
+	pollInterval  = time.Second
+	lease         = 30 * time.Second
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+	// sentTTL сколько хранится отправленное сообщение. Неотправленные не удаляются: у них нет sent_at
+	sentTTL = 24 * time.Hour
+)
+
+// Статусы сообщения
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+)
+
+// Message команда для Kafka вместе с поездкой, которую нужно сохранить до ее отправки.
+// Запись сообщения - единственная атомарная операция запроса, дальше relay доводит дело до конца
+type Message struct {
+	ID            string       `bson:"id"`
+	Key           string       `bson:"key"`
+	Payload       []byte       `bson:"payload"`
+	Trip          *models.Trip `bson:"trip,omitempty"`
+	Status        string       `bson:"status"`
+	Attempts      int          `bson:"attempts"`
+	LastError     string       `bson:"last_error,omitempty"`
+	NextAttemptAt time.Time    `bson:"next_attempt_at"`
+	CreatedAt     time.Time    `bson:"created_at"`
+	SentAt        time.Time    `bson:"sent_at,omitempty"`
+}
+
+// Relay сохраняет поездки из outbox и отправляет команды в Kafka
+type Relay struct {
+	coll   *mongo.Collection
+	trips  *mongo.Collection
+	writer *kafka.Writer
+}
+
+func NewRelay(database *mongo.Database, trips *mongo.Collection, writer *kafka.Writer) *Relay {
+	return &Relay{
+		coll:   database.Collection(collName),
+		trips:  trips,
+		writer: writer,
+	}
+}
+
+// EnsureIndexes создает индексы outbox и TTL индекс отправленных сообщений
+func (r *Relay) EnsureIndexes(ctx context.Context) error {
+	_, err := r.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "sent_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(sentTTL.Seconds()))},
+	})
+	return err
+}
+
+// Add сохраняет сообщение. Relay не трогает его в течение lease, чтобы вызывающий успел
+// обработать его сам через Process
+func (r *Relay) Add(ctx context.Context, message *Message) error {
+	now := time.Now().UTC()
+	message.Status = StatusPending
+	message.NextAttemptAt = now.Add(lease)
+	message.CreatedAt = now
+
+	_, err := r.coll.InsertOne(ctx, message)
+	return err
+}
+
+// Process сохраняет поездку, отправляет команду и отмечает сообщение отправленным.
+// Каждый шаг идемпотентен, поэтому повтор после сбоя на любом шаге безопасен
+func (r *Relay) Process(ctx context.Context, message *Message) error {
+	if message.Trip != nil {
+		_, err := r.trips.UpdateOne(ctx, bson.M{"id": message.Trip.ID}, bson.M{"$setOnInsert": message.Trip},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := kfk.SendToTopic(ctx, r.writer, message.Key, message.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.coll.UpdateOne(ctx, bson.M{"id": message.ID},
+		bson.M{"$set": bson.M{"status": StatusSent, "sent_at": time.Now().UTC()}})
+	return err
+}
+
+// Run обрабатывает сообщения, которые не удалось обработать сразу, пока не завершится ctx
+func (r *Relay) Run(ctx context.Context) {
+	logger := zapctx.Logger(ctx)
+	logger.Info("Starting outbox relay")
+	for {
+		message, err := r.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Outbox claim error", zap.Error(err))
+		}
+
+		if message != nil {
+			err = r.Process(ctx, message)
+			if err != nil {
+				logger.Warn("Outbox message processing failed", zap.String("id", message.ID),
+					zap.Int("attempts", message.Attempts+1), zap.Error(err))
+				r.retry(ctx, message, err)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Outbox relay stopped")
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// claim забирает сообщение, время которого пришло, и откладывает его на lease для других реплик
+func (r *Relay) claim(ctx context.Context) (*Message, error) {
+	now := time.Now().UTC()
+	var message Message
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"status": StatusPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}).SetReturnDocument(options.After),
+	).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// retry откладывает сообщение с экспоненциальной задержкой
+func (r *Relay) retry(ctx context.Context, message *Message, cause error) {
+	attempts := message.Attempts + 1
+	_, err := r.coll.UpdateOne(ctx, bson.M{"id": message.ID}, bson.M{"$set": bson.M{
+		"attempts":        attempts,
+		"last_error":      cause.Error(),
+		"next_attempt_at": time.Now().UTC().Add(retryDelay(attempts)),
+	}})
+	if err != nil && ctx.Err() == nil {
+		zapctx.Logger(ctx).Error("Outbox retry update error", zap.Error(err))
+	}
+}
+
+// retryDelay экспоненциальная задержка перед повтором
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// NewTripMessage сообщение с поездкой и командой trip.command.create для нее.
+// Id команды совпадает с id поездки, поэтому trip отбросит повтор
+func NewTripMessage(trip *models.Trip) (*Message, error) {
+	data, err := json.Marshal(models.CommandCreateData{OfferId: trip.OfferID})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(models.Request{
+		Id:              trip.ID,
+		Source:          "/client",
+		Type:            "trip.command.create",
+		DataContentType: "application/json",
+		Time:            time.Now().UTC(),
+		Data:            data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ID:      trip.ID,
+		Key:     trip.ID,
+		Payload: payload,
+		Trip:    trip,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package places
+
+import (
+	"context"
+	"errors"
+	"final-project/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// collName коллекция сохраненных мест рядом с коллекцией поездок
+const collName = "places"
+
+var (
+	ErrPlaceNotFound = errors.New("place not found")
+	// ErrPlaceExists у пользователя уже есть место с таким названием
+	ErrPlaceExists = errors.New("place with this name already exists")
+)
+
+// Place сохраненное место пользователя, например дом или работа
+type Place struct {
+	ID        string          `bson:"id" json:"id"`
+	UserID    string          `bson:"user_id" json:"-"`
+	Name      string          `bson:"name" json:"name"`
+	Address   string          `bson:"address,omitempty" json:"address,omitempty"`
+	Location  models.Location `bson:"location" json:"location"`
+	CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// Store хранит места в MongoDB
+type Store struct {
+	coll *mongo.Collection
+}
+
+func NewStore(database *mongo.Database) *Store {
+	return &Store{coll: database.Collection(collName)}
+}
+
+// EnsureIndexes создает индексы мест. Название места уникально в пределах пользователя
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+// Create сохраняет место и выдает ему id
+func (s *Store) Create(ctx context.Context, place *Place) error {
+	now := time.Now().UTC()
+	place.ID = uuid.NewString()
+	place.CreatedAt = now
+	place.UpdatedAt = now
+
+	_, err := s.coll.InsertOne(ctx, place)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPlaceExists
+	}
+	return err
+}
+
+// List возвращает места пользователя по названию
+func (s *Store) List(ctx context.Context, userID string) ([]Place, error) {
+	cursor, err := s.coll.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	places := []Place{}
+	err = cursor.All(ctx, &places)
+	return places, err
+}
+
+// Get возвращает место пользователя
+func (s *Store) Get(ctx context.Context, userID string, placeID string) (*Place, error) {
+	var place Place
+	err := s.coll.FindOne(ctx, bson.M{"id": placeID, "user_id": userID}).Decode(&place)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPlaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &place, nil
+}
+
+// Update заменяет название, адрес и координаты места
+func (s *Store) Update(ctx context.Context, place *Place) error {
+	place.UpdatedAt = time.Now().UTC()
+	err := s.coll.FindOneAndUpdate(ctx, bson.M{"id": place.ID, "user_id": place.UserID},
+		bson.M{"$set": bson.M{
+			"name":       place.Name,
+			"address":    place.Address,
+			"location":   place.Location,
+			"updated_at": place.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(place)
+	if err == mongo.ErrNoDocuments {
+		return ErrPlaceNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPlaceExists
+	}
+	return err
+}
+
+// Delete удаляет место. Уже созданные по нему поездки хранят свои координаты и не меняются
+func (s *Store) Delete(ctx context.Context, userID string, placeID string) error {
+	result, err := s.coll.DeleteOne(ctx, bson.M{"id": placeID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrPlaceNotFound
+	}
+
+	return nil
+}
+
+// Resolve возвращает координаты точки маршрута: сохраненного места, если задан place_id, иначе ее собственные
+func (s *Store) Resolve(ctx context.Context, userID string, stop models.Stop) (models.Location, error) {
+	if stop.PlaceID == "" {
+		return stop.Location, nil
+	}
+
+	place, err := s.Get(ctx, userID, stop.PlaceID)
+	if err != nil {
+		return models.Location{}, err
+	}
+	return place.Location, nil
+}
@@ -0,0 +1,186 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"final-project/models"
+	kfk "final-project/pkg/kafka"
+	"github.com/juju/zaputil/zapctx"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// bufferSize сколько событий может ждать отправки одному подписчику
+const bufferSize = 16
+
+// Event событие поездки, рассылаемое подписчикам
+type Event struct {
+	Id     string    `json:"id"`
+	TripId string    `json:"trip_id"`
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+}
+
+// Subscription подписка на события одной поездки
+type Subscription struct {
+	C       <-chan Event
+	tripId  string
+	channel chan Event
+}
+
+// Broker раздает события поездок подписчикам этого экземпляра client.
+// Каждый экземпляр читает все партиции топика событий без группы, поэтому все реплики видят
+// все события и доставляют их тем сокетам, которые держат сами
+type Broker struct {
+	readers []*kafka.Reader
+
+	mutex       sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewBroker(readers []*kafka.Reader) *Broker {
+	return &Broker{
+		readers:     readers,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe подписывает на события поездки tripId. Подписку нужно закрыть через Unsubscribe
+func (b *Broker) Subscribe(tripId string) *Subscription {
+	channel := make(chan Event, bufferSize)
+	subscription := &Subscription{
+		C:       channel,
+		tripId:  tripId,
+		channel: channel,
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.subscribers[tripId] == nil {
+		b.subscribers[tripId] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[tripId][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Unsubscribe удаляет подписку и закрывает ее канал
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.remove(subscription)
+}
+
+// remove вызывается под mutex
+func (b *Broker) remove(subscription *Subscription) {
+	subscriptions, ok := b.subscribers[subscription.tripId]
+	if !ok {
+		return
+	}
+	if _, ok = subscriptions[subscription]; !ok {
+		return
+	}
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(b.subscribers, subscription.tripId)
+	}
+	close(subscription.channel)
+}
+
+// Run читает события из Kafka и рассылает их, пока не завершится ctx
+func (b *Broker) Run(ctx context.Context) {
+	logger := zapctx.Logger(ctx)
+	logger.Info("Starting pub/sub broker")
+
+	var wg sync.WaitGroup
+	for _, reader := range b.readers {
+		wg.Add(1)
+		go func(reader *kafka.Reader) {
+			defer wg.Done()
+			b.consume(ctx, reader)
+		}(reader)
+	}
+	wg.Wait()
+
+	logger.Info("Pub/sub broker stopped")
+}
+
+// consume читает одну партицию. Offset-ы не коммитятся: после перезапуска нужны только новые события
+func (b *Broker) consume(ctx context.Context, reader *kafka.Reader) {
+	logger := zapctx.Logger(ctx)
+	for {
+		message, err := kfk.ReadFromTopic(ctx, reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Pub/sub read error", zap.Error(err))
+			continue
+		}
+
+		event, err := decode(message.Value)
+		if err != nil {
+			logger.Warn("Pub/sub skipped message", zap.Error(err))
+			continue
+		}
+		b.publish(ctx, event)
+	}
+}
+
+// publish отправляет событие подписчикам поездки. Подписчик, не успевающий читать, отключается,
+// чтобы не задерживать остальных; клиент переподключится и получит актуальное состояние
+func (b *Broker) publish(ctx context.Context, event *Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for subscription := range b.subscribers[event.TripId] {
+		select {
+		case subscription.channel <- *event:
+		default:
+			zapctx.Logger(ctx).Warn("Slow subscriber disconnected", zap.String("trip_id", event.TripId))
+			b.remove(subscription)
+		}
+	}
+}
+
+// decode достает из CloudEvent id поездки
+func decode(bytes []byte) (*Event, error) {
+	var request models.Request
+	err := json.Unmarshal(bytes, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventData models.EventData
+	err = json.Unmarshal(request.Data, &eventData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Id:     request.Id,
+		TripId: eventData.TripId,
+		Type:   request.Type,
+		Time:   request.Time,
+	}, nil
+}
+
+// Close закрывает reader-ы и все подписки
+func (b *Broker) Close() error {
+	b.mutex.Lock()
+	for _, subscriptions := range b.subscribers {
+		for subscription := range subscriptions {
+			b.remove(subscription)
+		}
+	}
+	b.mutex.Unlock()
+
+	var closeErr error
+	for _, reader := range b.readers {
+		if err := reader.Close(); err != nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
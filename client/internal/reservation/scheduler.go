@@ -0,0 +1,224 @@
+package reservation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"final-project/internal/outbox"
+	"final-project/models"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/juju/zaputil/zapctx"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Параметры планировщика
+const (
+	pollInterval   = time.Second
+	lease          = time.Minute
+	requestTimeout = 10 * time.Second
+	maxAttempts    = 10
+	minRetryDelay  = 5 * time.Second
+	maxRetryDelay  = 5 * time.Minute
+	// offerMargin запас срока действия оффера: trip читает оффер уже после отправки команды
+	offerMargin = 5 * time.Minute
+)
+
+// Scheduler создает поездки по броням, когда подходит их время. Состояние хранится в MongoDB,
+// поэтому брони переживают перезапуск, а несколько реплик не создадут поездку дважды
+type Scheduler struct {
+	store           *Store
+	relay           *outbox.Relay
+	offeringAddress string
+	// serviceToken bearer токен client-а: бронь отправляется без пользователя
+	serviceToken string
+	client       *http.Client
+}
+
+func NewScheduler(store *Store, relay *outbox.Relay, offeringAddress string, serviceToken string) *Scheduler {
+	return &Scheduler{
+		store:           store,
+		relay:           relay,
+		offeringAddress: offeringAddress,
+		serviceToken:    serviceToken,
+		client:          &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Run отправляет брони, пока не завершится ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	logger := zapctx.Logger(ctx)
+	logger.Info("Starting trip scheduler")
+	for {
+		reservation, err := s.store.claim(ctx, lease)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Reservation claim error", zap.Error(err))
+		}
+
+		if reservation != nil {
+			err = s.dispatch(ctx, reservation)
+			if err != nil {
+				s.retry(ctx, reservation, err)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Trip scheduler stopped")
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// dispatch создает поездку брони через outbox. Повтор безопасен: id поездки выдан при бронировании
+func (s *Scheduler) dispatch(ctx context.Context, reservation *Reservation) error {
+	logger := zapctx.Logger(ctx).With(zap.String("reservation_id", reservation.ID))
+
+	// Истекающий оффер trip не примет, поэтому он перезапрашивается на тот же маршрут
+	if time.Until(reservation.OfferExpiresAt) < offerMargin {
+		err := s.requote(ctx, reservation)
+		if err != nil {
+			return fmt.Errorf("requote offer: %w", err)
+		}
+		logger.Info("Offer requoted", zap.Float64("price", reservation.Price.Amount))
+	}
+
+	now := time.Now().UTC()
+	scheduledAt := reservation.ScheduledAt
+	message, err := outbox.NewTripMessage(&models.Trip{
+		ID:          reservation.TripID,
+		UserID:      reservation.UserID,
+		OfferID:     reservation.OfferID,
+		From:        reservation.From,
+		To:          reservation.To,
+		Waypoints:   reservation.Waypoints,
+		Price:       reservation.Price,
+		Status:      "DRIVER_SEARCH",
+		ScheduledAt: &scheduledAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Сообщение уже в outbox после прошлой попытки - его доотправит relay
+	err = s.relay.Add(ctx, message)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	if err == nil {
+		err = s.relay.Process(ctx, message)
+		if err != nil {
+			logger.Warn("Outbox message left for relay", zap.String("trip_id", reservation.TripID), zap.Error(err))
+		}
+	}
+
+	logger.Info("Scheduled trip created", zap.String("trip_id", reservation.TripID))
+	return s.store.dispatched(ctx, reservation.ID)
+}
+
+// requote получает новый оффер на маршрут брони и сохраняет его
+func (s *Scheduler) requote(ctx context.Context, reservation *Reservation) error {
+	body, err := s.call(ctx, http.MethodPost, "/"+reservation.OfferID+"/requote")
+	if err != nil {
+		return err
+	}
+	var order models.OrderOffering
+	err = json.Unmarshal(body, &order)
+	if err != nil {
+		return err
+	}
+	if order.ClientID != reservation.UserID {
+		return errors.New("requoted offer belongs to another client")
+	}
+
+	expiresAt, err := OfferExpiry(order.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.requoted(ctx, reservation.ID, order.ID, expiresAt, order.Price)
+	if err != nil {
+		return err
+	}
+	reservation.OfferID = order.ID
+	reservation.OfferExpiresAt = expiresAt
+	reservation.Price = order.Price
+	return nil
+}
+
+// call выполняет запрос к offering и возвращает тело ответа 200
+func (s *Scheduler) call(ctx context.Context, method string, path string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, method, s.offeringAddress+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+s.serviceToken)
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("offering %v %v returned %v", method, path, response.StatusCode)
+	}
+	return body, nil
+}
+
+// retry откладывает бронь с экспоненциальной задержкой. Бронь не удалась, если кончились
+// попытки или следующая попытка была бы уже после scheduled_at
+func (s *Scheduler) retry(ctx context.Context, reservation *Reservation, cause error) {
+	logger := zapctx.Logger(ctx)
+	attempts := reservation.Attempts + 1
+	next := time.Now().UTC().Add(retryDelay(attempts))
+	failed := attempts >= maxAttempts || next.After(reservation.ScheduledAt)
+	if failed {
+		logger.Error("Reservation failed", zap.String("reservation_id", reservation.ID), zap.Error(cause))
+	} else {
+		logger.Warn("Reservation dispatch failed", zap.String("reservation_id", reservation.ID),
+			zap.Int("attempts", attempts), zap.Error(cause))
+	}
+
+	err := s.store.retry(ctx, reservation, cause, next, failed)
+	if err != nil && ctx.Err() == nil {
+		logger.Error("Reservation retry update error", zap.Error(err))
+	}
+}
+
+// retryDelay экспоненциальная задержка перед повтором
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// OfferExpiry возвращает срок действия оффера. Подпись не проверяется: оффер уже прочитан
+// через offering, здесь нужен только exp
+func OfferExpiry(offerID string) (time.Time, error) {
+	var claims jwt.RegisteredClaims
+	_, _, err := jwt.NewParser().ParseUnverified(offerID, &claims)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, errors.New("offer has no expiration time")
+	}
+	return claims.ExpiresAt.Time, nil
+}
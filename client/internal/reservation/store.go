@@ -0,0 +1,196 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+	"final-project/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// collName коллекция броней рядом с коллекцией поездок
+const collName = "reservations"
+
+// Статусы брони
+const (
+	StatusScheduled   = "scheduled"
+	StatusDispatching = "dispatching"
+	StatusDispatched  = "dispatched"
+	StatusCanceled    = "canceled"
+	StatusFailed      = "failed"
+)
+
+// Ограничения времени поездки
+const (
+	// LeadTime за сколько до scheduled_at отправляется команда создания поездки (начинается поиск водителя)
+	LeadTime = 15 * time.Minute
+	// MaxAhead насколько вперед можно забронировать поездку
+	MaxAhead = 30 * 24 * time.Hour
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrNotCancelable бронь уже отправлена (отменяется сама поездка), отменена или не удалась
+	ErrNotCancelable = errors.New("reservation can not be canceled")
+)
+
+// Reservation поездка на время. TripID выдается при бронировании, с ним же будет создана поездка
+type Reservation struct {
+	ID             string            `bson:"id" json:"id"`
+	UserID         string            `bson:"user_id" json:"-"`
+	TripID         string            `bson:"trip_id" json:"trip_id"`
+	OfferID        string            `bson:"offer_id" json:"offer_id"`
+	OfferExpiresAt time.Time         `bson:"offer_expires_at" json:"-"`
+	From           models.Location   `bson:"from" json:"from"`
+	To             models.Location   `bson:"to" json:"to"`
+	Waypoints      []models.Location `bson:"waypoints,omitempty" json:"waypoints,omitempty"`
+	Price          models.Price      `bson:"price" json:"price"`
+	ScheduledAt    time.Time         `bson:"scheduled_at" json:"scheduled_at"`
+	DispatchAt     time.Time         `bson:"dispatch_at" json:"dispatch_at"`
+	Status         string            `bson:"status" json:"status"`
+	Attempts       int               `bson:"attempts" json:"-"`
+	LastError      string            `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time         `bson:"next_attempt_at" json:"-"`
+	CreatedAt      time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time         `bson:"updated_at" json:"updated_at"`
+}
+
+// Store хранит брони в MongoDB
+type Store struct {
+	coll *mongo.Collection
+}
+
+func NewStore(database *mongo.Database) *Store {
+	return &Store{coll: database.Collection(collName)}
+}
+
+// EnsureIndexes создает индексы броней
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "scheduled_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+	})
+	return err
+}
+
+// Create сохраняет бронь. Команда будет отправлена за LeadTime до scheduled_at
+func (s *Store) Create(ctx context.Context, reservation *Reservation) error {
+	now := time.Now().UTC()
+	reservation.ID = uuid.NewString()
+	reservation.TripID = uuid.NewString()
+	reservation.DispatchAt = reservation.ScheduledAt.Add(-LeadTime)
+	reservation.Status = StatusScheduled
+	reservation.NextAttemptAt = reservation.DispatchAt
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	_, err := s.coll.InsertOne(ctx, reservation)
+	return err
+}
+
+// List возвращает брони пользователя по scheduled_at, пустой status - все
+func (s *Store) List(ctx context.Context, userID string, status string) ([]Reservation, error) {
+	filter := bson.M{"user_id": userID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "scheduled_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	reservations := []Reservation{}
+	err = cursor.All(ctx, &reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// Cancel отменяет бронь, если команда создания поездки еще не отправлялась
+func (s *Store) Cancel(ctx context.Context, userID string, id string) (*Reservation, error) {
+	var reservation Reservation
+	err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"id": id, "user_id": userID, "status": StatusScheduled},
+		bson.M{"$set": bson.M{"status": StatusCanceled, "updated_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err != mongo.ErrNoDocuments {
+		if err != nil {
+			return nil, err
+		}
+		return &reservation, nil
+	}
+
+	// Бронь не найдена или уже в другом статусе
+	err = s.coll.FindOne(ctx, bson.M{"id": id, "user_id": userID}).Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrNotCancelable
+}
+
+// claim забирает бронь, время отправки которой пришло, или зависшую после сбоя реплики.
+// Статус dispatching не дает отменить бронь, пока создается поездка
+func (s *Store) claim(ctx context.Context, lease time.Duration) (*Reservation, error) {
+	now := time.Now().UTC()
+	var reservation Reservation
+	err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{
+			"status":          bson.M{"$in": bson.A{StatusScheduled, StatusDispatching}},
+			"next_attempt_at": bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{"status": StatusDispatching, "next_attempt_at": now.Add(lease), "updated_at": now}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}).SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// requoted сохраняет новый оффер, чтобы повтор после сбоя не перезапрашивал его
+func (s *Store) requoted(ctx context.Context, id string, offerID string, expiresAt time.Time, price models.Price) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": bson.M{
+		"offer_id":         offerID,
+		"offer_expires_at": expiresAt,
+		"price":            price,
+		"updated_at":       time.Now().UTC(),
+	}})
+	return err
+}
+
+// dispatched отмечает, что поездка создана
+func (s *Store) dispatched(ctx context.Context, id string) error {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"id": id, "status": StatusDispatching},
+		bson.M{"$set": bson.M{"status": StatusDispatched, "updated_at": time.Now().UTC()}})
+	return err
+}
+
+// retry откладывает бронь до nextAttemptAt или, если попытки кончились, отмечает ее неудавшейся
+func (s *Store) retry(ctx context.Context, reservation *Reservation, cause error, nextAttemptAt time.Time, failed bool) error {
+	status := StatusDispatching
+	if failed {
+		status = StatusFailed
+	}
+	_, err := s.coll.UpdateOne(ctx, bson.M{"id": reservation.ID, "status": StatusDispatching}, bson.M{"$set": bson.M{
+		"status":          status,
+		"attempts":        reservation.Attempts + 1,
+		"last_error":      cause.Error(),
+		"next_attempt_at": nextAttemptAt,
+		"updated_at":      time.Now().UTC(),
+	}})
+	return err
+}
@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/juju/zaputil/zapctx"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Параметры доставки
+const (
+	pollInterval   = time.Second
+	requestTimeout = 10 * time.Second
+	lease          = time.Minute
+	maxAttempts    = 10
+	minRetryDelay  = 5 * time.Second
+	maxRetryDelay  = time.Hour
+)
+
+// Заголовки запроса доставки. Подпись - hex HMAC-SHA256 секрета webhook-а от "<timestamp>.<тело>"
+const (
+	HeaderWebhookID = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Dispatcher отправляет ожидающие доставки
+type Dispatcher struct {
+	store  *Store
+	client *http.Client
+}
+
+func NewDispatcher(store *Store) *Dispatcher {
+	// Без прокси из окружения: адрес проверяется при подключении к самому получателю
+	transport := &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: requestTimeout, Control: dialControl}).DialContext,
+		TLSHandshakeTimeout: requestTimeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: requestTimeout, Transport: transport},
+	}
+}
+
+// Run отправляет доставки, пока не завершится ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger := zapctx.Logger(ctx)
+	logger.Info("Starting webhook dispatcher")
+	for {
+		delivery, err := d.store.claim(ctx, lease)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Webhook delivery claim error", zap.Error(err))
+		}
+
+		if delivery != nil {
+			err = d.deliver(ctx, delivery)
+			if err != nil {
+				logger.Error("Webhook delivery record error", zap.Error(err), zap.String("delivery_id", delivery.ID))
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Webhook dispatcher stopped")
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// deliver выполняет одну попытку и записывает ее результат
+func (d *Dispatcher) deliver(ctx context.Context, delivery *Delivery) error {
+	webhook, err := d.store.webhook(ctx, delivery.WebhookID)
+	if err == ErrWebhookNotFound {
+		return d.store.record(ctx, delivery.ID, &Attempt{At: time.Now().UTC(), Error: err.Error()},
+			StatusFailed, time.Now().UTC())
+	}
+	if err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	statusCode, err := d.send(ctx, webhook, delivery)
+	attempt := Attempt{
+		At:         startTime.UTC(),
+		StatusCode: statusCode,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}
+	if err == nil && (statusCode < 200 || statusCode > 299) {
+		err = fmt.Errorf("unexpected status code %v", statusCode)
+	}
+	if err == nil {
+		return d.store.record(ctx, delivery.ID, &attempt, StatusDelivered, attempt.At)
+	}
+
+	// Неудачная попытка: повтор с экспоненциальной задержкой или отказ после maxAttempts
+	attempt.Error = err.Error()
+	attempts := len(delivery.Attempts) + 1
+	if attempts >= maxAttempts {
+		zapctx.Logger(ctx).Warn("Webhook delivery failed", zap.String("delivery_id", delivery.ID),
+			zap.Int("attempts", attempts), zap.Error(err))
+		return d.store.record(ctx, delivery.ID, &attempt, StatusFailed, attempt.At)
+	}
+	return d.store.record(ctx, delivery.ID, &attempt, StatusPending, time.Now().UTC().Add(retryDelay(attempts)))
+}
+
+// send отправляет подписанный payload, возвращает код ответа
+func (d *Dispatcher) send(ctx context.Context, webhook *Webhook, delivery *Delivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Подписки, созданные до проверки адресов, могут вести на http
+	target, err := url.Parse(webhook.URL)
+	if err != nil || target.Scheme != "https" {
+		return 0, ErrForbiddenTarget
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/cloudevents+json")
+	request.Header.Set(HeaderWebhookID, webhook.ID)
+	request.Header.Set(HeaderDelivery, delivery.ID)
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	return response.StatusCode, nil
+}
+
+// Sign считает подпись тела запроса
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay экспоненциальная задержка перед попыткой attempts+1
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Коллекции рядом с коллекцией поездок
+const (
+	webhooksCollName   = "webhooks"
+	deliveriesCollName = "webhook_deliveries"
+)
+
+// Статусы доставки
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook подписка пользователя или партнера на события поездок
+type Webhook struct {
+	ID        string    `bson:"id" json:"id"`
+	UserID    string    `bson:"user_id" json:"-"`
+	URL       string    `bson:"url" json:"url"`
+	Secret    string    `bson:"secret" json:"secret,omitempty"`
+	Events    []string  `bson:"events" json:"events"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Delivery доставка одного события в один webhook вместе с журналом попыток
+type Delivery struct {
+	ID            string    `bson:"id" json:"id"`
+	WebhookID     string    `bson:"webhook_id" json:"webhook_id"`
+	UserID        string    `bson:"user_id" json:"-"`
+	EventID       string    `bson:"event_id" json:"event_id"`
+	EventType     string    `bson:"event_type" json:"event_type"`
+	Payload       []byte    `bson:"payload" json:"-"`
+	Status        string    `bson:"status" json:"status"`
+	Attempts      []Attempt `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `bson:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Attempt одна попытка доставки
+type Attempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// Store хранит подписки и журнал доставок в MongoDB
+type Store struct {
+	webhooks   *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+func NewStore(database *mongo.Database) *Store {
+	return &Store{
+		webhooks:   database.Collection(webhooksCollName),
+		deliveries: database.Collection(deliveriesCollName),
+	}
+}
+
+// EnsureIndexes создает индексы коллекций
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.webhooks.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		// Одно событие доставляется в webhook один раз, даже если Kafka прислала его повторно
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// Create сохраняет подписку и генерирует ей id и секрет подписи
+func (s *Store) Create(ctx context.Context, userID string, url string, events []string) (*Webhook, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []string{}
+	}
+
+	webhook := Webhook{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		URL:       url,
+		Secret:    hex.EncodeToString(secret),
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err = s.webhooks.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// List возвращает подписки пользователя без секретов
+func (s *Store) List(ctx context.Context, userID string) ([]Webhook, error) {
+	cursor, err := s.webhooks.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetProjection(bson.M{"secret": 0}).SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := []Webhook{}
+	err = cursor.All(ctx, &webhooks)
+	return webhooks, err
+}
+
+// Delete удаляет подписку. Ожидающие доставки в нее больше не отправляются
+func (s *Store) Delete(ctx context.Context, userID string, webhookID string) error {
+	result, err := s.webhooks.DeleteOne(ctx, bson.M{"id": webhookID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+
+	_, err = s.deliveries.UpdateMany(ctx, bson.M{"webhook_id": webhookID, "status": StatusPending},
+		bson.M{"$set": bson.M{"status": StatusFailed}})
+	return err
+}
+
+// Deliveries возвращает журнал доставок webhook-а, новые первыми. Пустой status - все статусы
+func (s *Store) Deliveries(ctx context.Context, userID string, webhookID string, status string, limit int64) ([]Delivery, error) {
+	count, err := s.webhooks.CountDocuments(ctx, bson.M{"id": webhookID, "user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	filter := bson.M{"webhook_id": webhookID}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := s.deliveries.Find(ctx, filter,
+		options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []Delivery{}
+	err = cursor.All(ctx, &deliveries)
+	return deliveries, err
+}
+
+// Enqueue ставит событие в очередь доставки во все подходящие webhook-и пользователя.
+// Повторный вызов для того же события не создает дублей
+func (s *Store) Enqueue(ctx context.Context, userID string, eventID string, eventType string, payload []byte) error {
+	cursor, err := s.webhooks.Find(ctx, bson.M{
+		"user_id": userID,
+		// Пустой список событий - подписка на все
+		"$or": bson.A{bson.M{"events": bson.M{"$size": 0}}, bson.M{"events": eventType}},
+	})
+	if err != nil {
+		return err
+	}
+	var webhooks []Webhook
+	err = cursor.All(ctx, &webhooks)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, webhook := range webhooks {
+		_, err = s.deliveries.InsertOne(ctx, Delivery{
+			ID:            uuid.NewString(),
+			WebhookID:     webhook.ID,
+			UserID:        userID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        StatusPending,
+			Attempts:      []Attempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claim забирает доставку, время которой пришло, и откладывает ее на lease,
+// чтобы другие реплики не отправили ее одновременно
+func (s *Store) claim(ctx context.Context, lease time.Duration) (*Delivery, error) {
+	now := time.Now().UTC()
+	var delivery Delivery
+	err := s.deliveries.FindOneAndUpdate(ctx,
+		bson.M{"status": StatusPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}).SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// webhook возвращает подписку с секретом
+func (s *Store) webhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	var webhook Webhook
+	err := s.webhooks.FindOne(ctx, bson.M{"id": webhookID}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// record добавляет попытку в журнал и выставляет новый статус и время следующей попытки
+func (s *Store) record(ctx context.Context, deliveryID string, attempt *Attempt, status string, nextAttemptAt time.Time) error {
+	_, err := s.deliveries.UpdateOne(ctx, bson.M{"id": deliveryID}, bson.M{
+		"$push": bson.M{"attempts": attempt},
+		"$set":  bson.M{"status": status, "next_attempt_at": nextAttemptAt},
+	})
+	return err
+}
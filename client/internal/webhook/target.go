@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenTarget адрес доставки ведет не в публичную сеть: loopback, частные и link-local
+// сети, адрес метаданных облака, внутренние имена сервисов
+var ErrForbiddenTarget = errors.New("webhook target is not a public https address")
+
+// Сети, которые не попадают под netip.Addr.IsPrivate и IsLinkLocal*, но тоже не публичные
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// ValidateURL проверяет адрес доставки: абсолютный https URL, все адреса хоста публичные.
+// Проверка при создании подписки не защищает от смены DNS записи, поэтому адрес еще раз
+// проверяется при подключении (см. dialControl)
+func ValidateURL(ctx context.Context, raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || target.Scheme != "https" || target.Hostname() == "" || target.User != nil {
+		return ErrForbiddenTarget
+	}
+
+	addresses, err := net.DefaultResolver.LookupNetIP(ctx, "ip", target.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenTarget, err)
+	}
+	for _, address := range addresses {
+		if !publicAddr(address) {
+			return ErrForbiddenTarget
+		}
+	}
+
+	return nil
+}
+
+// dialControl запрещает подключение к непубличным адресам. Вызывается для уже разрешенного адреса,
+// поэтому подмена DNS записи после ValidateURL не помогает
+func dialControl(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// publicAddr адрес из публичной unicast сети
+func publicAddr(address netip.Addr) bool {
+	address = address.Unmap()
+	if !address.IsGlobalUnicast() || address.IsPrivate() {
+		return false
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(address) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"shared/cancellation"
 	"time"
 )
 
@@ -9,40 +10,89 @@ type Config struct {
 	MongoIRI        string `json:"mongoIRI"`
 	OfferingAddress string `json:"offeringAddress"`
 	KafkaAddress    string `json:"kafkaAddress"`
+	KafkaPartitions int    `json:"kafkaPartitions"`
 	ServeAddress    string `json:"serveAddress"`
 	BasePath        string `json:"basePath"`
 	CollName        string `json:"collName"`
 	DatabaseName    string `json:"databaseName"`
 	JaegerAddress   string `json:"jaegerAddress"`
+	JWKSPath        string `json:"jwksPath"`
+	JWKSURL         string `json:"jwksURL"`
+	JWTIssuer       string `json:"jwtIssuer"`
+	JWTAudience     string `json:"jwtAudience"`
+	// ServiceTokenPath файл с bearer токеном client-а для перезапроса офферов броней в offering
+	ServiceTokenPath string `json:"serviceTokenPath"`
+	ServiceToken     string `json:"-"`
+	// AllowedOrigins origin-ы браузерных приложений, которым разрешен WebSocket
+	AllowedOrigins []string `json:"allowedOrigins"`
+	// CancellationPolicy штраф за отмену по статусу поездки, для оценки в ответе на отмену.
+	// Должна совпадать с cancellationPolicy сервиса trip, который начисляет штраф
+	CancellationPolicy map[string]cancellation.Rule `json:"cancellationPolicy"`
 }
 
 type Location struct {
-	Lat float64 `bson:"lat"`
-	Lng float64 `bson:"lng"`
+	Lat float64 `bson:"lat" json:"lat"`
+	Lng float64 `bson:"lng" json:"lng"`
 }
 
 type Price struct {
-	Amount   float64 `bson:"amount"`
-	Currency string  `bson:"currency"`
+	Amount   float64 `bson:"amount" json:"amount"`
+	Currency string  `bson:"currency" json:"currency"`
 }
 
+// Trip проекция событий поездки. Поля событий заполняются по мере их прихода
 type Trip struct {
-	ID      string   `bson:"id"`
-	UserID  string   `bson:"user_id"`
-	OfferID string   `bson:"offer_id"`
-	From    Location `bson:"from"`
-	To      Location `bson:"to"`
-	Price   Price    `bson:"price"`
-	Status  string   `bson:"status"`
+	ID           string          `bson:"id"`
+	UserID       string          `bson:"user_id"`
+	OfferID      string          `bson:"offer_id"`
+	From         Location        `bson:"from"`
+	To           Location        `bson:"to"`
+	Waypoints    []Location      `bson:"waypoints,omitempty"`
+	Price        Price           `bson:"price"`
+	Status       string          `bson:"status"`
+	DriverID     string          `bson:"driver_id,omitempty"`
+	CancelReason string          `bson:"cancel_reason,omitempty"`
+	CancelFee    *Price          `bson:"cancel_fee,omitempty"`
+	CanceledBy   string          `bson:"canceled_by,omitempty"`
+	AcceptedAt   *time.Time      `bson:"accepted_at,omitempty"`
+	ArrivedAt    *time.Time      `bson:"arrived_at,omitempty"`
+	StartedAt    *time.Time      `bson:"started_at,omitempty"`
+	EndedAt      *time.Time      `bson:"ended_at,omitempty"`
+	CanceledAt   *time.Time      `bson:"canceled_at,omitempty"`
+	Timeline     []TimelineEntry `bson:"timeline,omitempty"`
+	ScheduledAt  *time.Time      `bson:"scheduled_at,omitempty"`
+	CreatedAt    time.Time       `bson:"created_at"`
+	UpdatedAt    time.Time       `bson:"updated_at"`
+}
+
+// TimelineEntry переход поездки в статус. Записи только добавляются, по одной на событие
+type TimelineEntry struct {
+	Status  string    `bson:"status" json:"status"`
+	Type    string    `bson:"type" json:"type"`
+	EventID string    `bson:"event_id" json:"event_id"`
+	Time    time.Time `bson:"time" json:"time"`
 }
 
 type OmitUserTrip struct {
-	ID      string   `bson:"id"`
-	OfferID string   `bson:"offer_id"`
-	From    Location `bson:"from"`
-	To      Location `bson:"to"`
-	Price   Price    `bson:"price"`
-	Status  string   `bson:"status"`
+	ID           string     `bson:"id" json:"id"`
+	OfferID      string     `bson:"offer_id" json:"offer_id"`
+	From         Location   `bson:"from" json:"from"`
+	To           Location   `bson:"to" json:"to"`
+	Waypoints    []Location `bson:"waypoints,omitempty" json:"waypoints,omitempty"`
+	Price        Price      `bson:"price" json:"price"`
+	Status       string     `bson:"status" json:"status"`
+	DriverID     string     `bson:"driver_id,omitempty" json:"driver_id,omitempty"`
+	CancelReason string     `bson:"cancel_reason,omitempty" json:"cancel_reason,omitempty"`
+	CancelFee    *Price     `bson:"cancel_fee,omitempty" json:"cancel_fee,omitempty"`
+	CanceledBy   string     `bson:"canceled_by,omitempty" json:"canceled_by,omitempty"`
+	AcceptedAt   *time.Time `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	ArrivedAt    *time.Time `bson:"arrived_at,omitempty" json:"arrived_at,omitempty"`
+	StartedAt    *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	EndedAt      *time.Time `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+	CanceledAt   *time.Time `bson:"canceled_at,omitempty" json:"canceled_at,omitempty"`
+	ScheduledAt  *time.Time `bson:"scheduled_at,omitempty" json:"scheduled_at,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
 }
 
 type LocationOffering struct {
@@ -55,15 +105,34 @@ type PriceOffering struct {
 	Currency string  `json:"currency"`
 }
 
+// OrderOffering оффер offering. ID - сам подписанный оффер, его передают как offer_id
 type OrderOffering struct {
-	From     Location `json:"from"`
-	To       Location `json:"to"`
-	ClientID string   `json:"client_id"`
-	Price    Price    `json:"price"`
+	ID        string     `json:"id"`
+	From      Location   `json:"from"`
+	To        Location   `json:"to"`
+	Waypoints []Location `json:"waypoints"`
+	ClientID  string     `json:"client_id"`
+	Price     Price      `json:"price"`
+}
+
+// Stop точка маршрута в запросе: координаты или id сохраненного места (place_id важнее координат)
+type Stop struct {
+	Location
+	PlaceID string `json:"place_id,omitempty"`
+}
+
+// Route маршрут запроса оффера
+type Route struct {
+	From      Stop   `json:"from"`
+	To        Stop   `json:"to"`
+	Waypoints []Stop `json:"waypoints,omitempty"`
 }
 
+// Offer тело POST /trips. Без offer_id client сам запрашивает оффер на маршрут Route
 type Offer struct {
-	OfferID string `json:"offer_id"`
+	OfferID     string     `json:"offer_id"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	*Route
 }
 
 type Request struct {
@@ -81,11 +150,48 @@ type CommandCreateData struct {
 	OfferId string `json:"offer_id"`
 }
 
+// CommandCancelData отмена поездки. Fee - штраф, показанный клиенту, пустой при бесплатной отмене
 type CommandCancelData struct {
-	TripId string `json:"trip_id"`
-	Reason string `json:"reason"`
+	TripId     string `json:"trip_id"`
+	Reason     string `json:"reason"`
+	CanceledBy string `json:"canceled_by"`
+	Fee        Price  `json:"fee"`
 }
 
+// EventData поля data событий поездки, у каждого события заполнена своя часть
 type EventData struct {
-	TripId string `json:"trip_id"`
+	TripId     string `json:"trip_id"`
+	DriverId   string `json:"driver_id"`
+	Reason     string `json:"reason"`
+	CanceledBy string `json:"canceled_by"`
+	Fee        Price  `json:"fee"`
+}
+
+// DeadLetter сообщение DLQ: исходный payload и описание ошибки обработки
+type DeadLetter struct {
+	Service     string    `json:"service"`
+	SourceTopic string    `json:"source_topic"`
+	Key         string    `json:"key"`
+	Stage       string    `json:"stage"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	FailedAt    time.Time `json:"failed_at"`
+	Payload     []byte    `json:"payload"`
+}
+
+// StatusHistory история смен статуса поездок пользователя, один документ на пользователя
+type StatusHistory struct {
+	UserID string         `bson:"_id"`
+	Seq    int64          `bson:"seq"`
+	Events []StatusChange `bson:"events"`
+}
+
+// StatusChange смена статуса поездки. Seq растет в пределах пользователя и служит id события SSE
+type StatusChange struct {
+	Seq     int64     `bson:"seq"`
+	EventID string    `bson:"event_id"`
+	TripID  string    `bson:"trip_id"`
+	Type    string    `bson:"type"`
+	Status  string    `bson:"status"`
+	Time    time.Time `bson:"time"`
 }
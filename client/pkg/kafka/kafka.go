@@ -3,28 +3,104 @@ package kafka
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
+	"net"
+	"strconv"
 )
 
-// SendToTopic отправляет message в conn Kafka
-func SendToTopic(conn *kafka.Conn, message []byte) error {
-	_, err := conn.WriteMessages(
-		kafka.Message{Value: message},
+// NewWriter создает writer в topic. Сообщения с одинаковым ключом попадают в одну партицию
+func NewWriter(address string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(address),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}
+}
+
+// SendToTopic отправляет message с ключом key в writer Kafka
+func SendToTopic(ctx context.Context, writer *kafka.Writer, key string, message []byte) error {
+	return writer.WriteMessages(ctx,
+		kafka.Message{Key: []byte(key), Value: message},
 	)
-	return err
 }
 
-// ReadFromTopic читает из conn Kafka
-func ReadFromTopic(conn *kafka.Conn) ([]byte, error) {
-	// Считываем 10КБ
-	b := make([]byte, 10e3)
-	n, err := conn.Read(b)
+// NewReader создает reader группы groupID. Offset-ы коммитятся только явно, через CommitMessage
+func NewReader(address string, topic string, groupID string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     []string{address},
+		GroupID:     groupID,
+		Topic:       topic,
+		StartOffset: kafka.FirstOffset,
+		MaxBytes:    10e6,
+	})
+}
+
+// NewTailReaders создает по reader-у без группы на каждую партицию topic. Reader-ы читают только
+// новые сообщения и не коммитят offset-ы, поэтому в Kafka после них ничего не остается
+func NewTailReaders(address string, topic string) ([]*kafka.Reader, error) {
+	conn, err := kafka.Dial("tcp", address)
 	if err != nil {
 		return nil, err
 	}
-	return b[:n], nil
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]*kafka.Reader, 0, len(partitions))
+	for _, partition := range partitions {
+		readers = append(readers, kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     []string{address},
+			Topic:       topic,
+			Partition:   partition.ID,
+			StartOffset: kafka.LastOffset,
+			MaxBytes:    10e6,
+		}))
+	}
+
+	return readers, nil
+}
+
+// ReadFromTopic читает следующее сообщение, не коммитя offset
+func ReadFromTopic(ctx context.Context, reader *kafka.Reader) (kafka.Message, error) {
+	return reader.FetchMessage(ctx)
+}
+
+// CommitMessage коммитит offset обработанного сообщения
+func CommitMessage(ctx context.Context, reader *kafka.Reader, message kafka.Message) error {
+	return reader.CommitMessages(ctx, message)
 }
 
-// ConnectKafka создает conn к Kafka
-func ConnectKafka(ctx context.Context, address string, topic string, partition int) (*kafka.Conn, error) {
-	return kafka.DialLeader(ctx, "tcp", address, topic, partition)
+// CreateTopics создает topics с заданным числом партиций, существующие топики не меняются
+func CreateTopics(address string, partitions int, topics ...string) error {
+	conn, err := kafka.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Топики создаются через контроллер кластера
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+
+	configs := make([]kafka.TopicConfig, 0, len(topics))
+	for _, topic := range topics {
+		configs = append(configs, kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     partitions,
+			ReplicationFactor: 1,
+		})
+	}
+
+	return controllerConn.CreateTopics(configs...)
 }
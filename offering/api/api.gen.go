@@ -0,0 +1,455 @@
+// Package api provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.1 DO NOT EDIT.
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/oapi-codegen/runtime"
+)
+
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// LatlngLiteral An object describing a specific location with Latitude and Longitude in decimal degrees.
+type LatlngLiteral struct {
+	// Lat Latitude in decimal degrees
+	Lat float32 `json:"lat"`
+
+	// Lng Longitude in decimal degrees
+	Lng float32 `json:"lng"`
+}
+
+// Money defines model for Money.
+type Money struct {
+	// Amount Amount expressed as a decimal number of major currency units
+	Amount float64 `json:"amount"`
+
+	// Currency 3 letter currency code as defined by ISO-4217
+	Currency string `json:"currency"`
+}
+
+// Offer Terms offered to the client
+type Offer struct {
+	ClientId *string `json:"client_id,omitempty"`
+
+	// From An object describing a specific location with Latitude and Longitude in decimal degrees.
+	From *LatlngLiteral `json:"from,omitempty"`
+
+	// Id Signed offer (JWT), valid for 8 hours. Pass it as offer_id to create a trip
+	Id    *string `json:"id,omitempty"`
+	Price *Money  `json:"price,omitempty"`
+
+	// To An object describing a specific location with Latitude and Longitude in decimal degrees.
+	To        *LatlngLiteral   `json:"to,omitempty"`
+	Waypoints *[]LatlngLiteral `json:"waypoints,omitempty"`
+}
+
+// Problem Error in RFC 7807 format, served as application/problem+json. Branch on code,
+// detail is a human-readable message and may change.
+type Problem struct {
+	// Code Stable machine-readable error code:
+	// * invalid_request - body is incorrect
+	// * unauthorized, invalid_token - missing or invalid bearer token
+	// * too_many_waypoints
+	// * offer_invalid - offer is malformed or its signature is wrong
+	// * offer_expired - offer is valid but expired, request a new one
+	// * internal_error
+	Code   string  `json:"code"`
+	Detail *string `json:"detail,omitempty"`
+	Status int     `json:"status"`
+
+	// Title HTTP status text
+	Title string `json:"title"`
+
+	// Type urn:problem:<code>
+	Type string `json:"type"`
+}
+
+// Unauthorized Error in RFC 7807 format, served as application/problem+json. Branch on code,
+// detail is a human-readable message and may change.
+type Unauthorized = Problem
+
+// CreateOfferJSONBody defines parameters for CreateOffer.
+type CreateOfferJSONBody struct {
+	// ClientId Ignored, client_id is taken from the bearer token subject
+	// Deprecated: this property has been marked as deprecated upstream, but no `x-deprecated-reason` was set
+	ClientId *string `json:"client_id,omitempty"`
+
+	// From An object describing a specific location with Latitude and Longitude in decimal degrees.
+	From *LatlngLiteral `json:"from,omitempty"`
+
+	// To An object describing a specific location with Latitude and Longitude in decimal degrees.
+	To *LatlngLiteral `json:"to,omitempty"`
+
+	// Waypoints Intermediate stops in visiting order, at most 10 (too_many_waypoints).
+	// The price is the sum of the route segments
+	Waypoints *[]LatlngLiteral `json:"waypoints,omitempty"`
+}
+
+// CreateOfferJSONRequestBody defines body for CreateOffer for application/json ContentType.
+type CreateOfferJSONRequestBody CreateOfferJSONBody
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+
+	// (POST /offers)
+	CreateOffer(w http.ResponseWriter, r *http.Request)
+
+	// (GET /offers/{offer_id})
+	ParseOffer(w http.ResponseWriter, r *http.Request, offerId string)
+
+	// (POST /offers/{offer_id}/requote)
+	RequoteOffer(w http.ResponseWriter, r *http.Request, offerId string)
+}
+
+// Unimplemented server implementation that returns http.StatusNotImplemented for each endpoint.
+
+type Unimplemented struct{}
+
+// (POST /offers)
+func (_ Unimplemented) CreateOffer(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// (GET /offers/{offer_id})
+func (_ Unimplemented) ParseOffer(w http.ResponseWriter, r *http.Request, offerId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// (POST /offers/{offer_id}/requote)
+func (_ Unimplemented) RequoteOffer(w http.ResponseWriter, r *http.Request, offerId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// CreateOffer operation middleware
+func (siw *ServerInterfaceWrapper) CreateOffer(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateOffer(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ParseOffer operation middleware
+func (siw *ServerInterfaceWrapper) ParseOffer(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "offer_id" -------------
+	var offerId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "offer_id", chi.URLParam(r, "offer_id"), &offerId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offer_id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ParseOffer(w, r, offerId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RequoteOffer operation middleware
+func (siw *ServerInterfaceWrapper) RequoteOffer(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "offer_id" -------------
+	var offerId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "offer_id", chi.URLParam(r, "offer_id"), &offerId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "offer_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RequoteOffer(w, r, offerId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{})
+}
+
+type ChiServerOptions struct {
+	BaseURL          string
+	BaseRouter       chi.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r chi.Router) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r chi.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, ChiServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = chi.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/offers", wrapper.CreateOffer)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/offers/{offer_id}", wrapper.ParseOffer)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/offers/{offer_id}/requote", wrapper.RequoteOffer)
+	})
+
+	return r
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+RY224bydF+lUL//4WVjCh65cBr3dmbLCJHzgqSDF1YgtCcKZJtz3RPunskMwYBSw7g",
+	"i13Eb7AXSR5Au7FiriTTr9DzRkF1Dw+joeR4swkS5IaYPlV/XYevqviCxSrLlURpDVt7wTSaXEmDfvBY",
+	"8sL2lRa/x4TGsZIWpaVPnuepiLkVSq7kWnVSzH7+1ChJaybuY8bp6/81dtka+7+V2SUrYdWsbIZTbDgc",
+	"RixBE2uRkzi2xh4JY4TsgdIg5CFPRQId5Bo1WPUMZQSxShCKOXRzWw/8HhaxPvIEtX/I7u7u8v3C9lFa",
+	"Ao11lHaQI1tjxmohe4SHEFUwaX2D21T2NoRFzVOaqKO9L0F1nmJsIcx3CDoHk2MsuiKGVAU9wZGwfdjg",
+	"VtgiQeAygQ0le2EkJCQYi4ynkGBPI5oWi1iuVY7aimCOlNvm7VN5TQksmjxNFlkHNRtGLJW9BTJugNEU",
+	"MoyYxt8VQpNTPPGogtz9iFlhUwyoNmYqm4oIeiIcj5TEASGpP5FnqpALXnnfzwM+zzUagwlwA3wKNSAD",
+	"1YWMP1Ua4kJrlPEACiksvQCf8ywnYPfute79ImJdpTNSJktU0UlxkaImMppYViFFa3HuGu+O3ECCXSEx",
+	"gc4A1re/Wr7z2e2785ezrccP2NzlwqjJnqsuWFdxpZU5UPsLVPpVt4u6CXcHdWZA0SImYBXYPkKcCvQS",
+	"6+oP0wciWRAWEetqlX0squuxMoyYSJqQtkWP1OQxwa2HuztLEYQw7yoNn0NfFdq0YJMbA8KSZv3WA+Hx",
+	"xxq5ReBgtcibuqM3iRg/BjQ44DBiVn3ym474IFeiYkxhMTOfLKICzbXmAzacTczMOeHHhvZ+pbVnO9j6",
+	"8gu4+3n7LgSPisCgPqxi4xp6bsEDzWXcByW910Z7MkHLRQqCAqpfZFwua+QJ76QIGRrDe4GqMj6AuM9l",
+	"D5vERJIWWNkGITzuC4kzqejx05m1PfmzKW2Tw6OxsAwdlQwIj5Cx0hpjS9vm2T6qcz0sQ3ZzxiABVqmD",
+	"jMvBwdR6NFs5VnVmOYzp8oynpNYqtVgDRvQkt4VGWj3SSvZm5/F5TrE6f74CUVioFiOYvJCDxCNQEsPz",
+	"LWrJ0wOvlkXuHAxE+p0xiY/1GyQvkmMst4WpybnTbk83EpBe4L6Kxa8a9Nc7O5sQpIDF57bGbQ94AlsB",
+	"xqLLw8RViYWWa5V7ru0V7fZqTG7hv7AmfX5jTeUf5U6/OnnSVAlR8NkmjZKeMC60sINtit3g4MGXqIKY",
+	"jb6c0PjD3R12tYJxf3Jjd+7O3Htw5+7CjeizPHGnEbgPbuzeug9uVB67U/fevS+/dj/4beUfy9du7C7B",
+	"jdw7eLj7m224FSvZFT14evTMbHLbp6ULN/Ljx1sbS6096b5135Vv3Dt3Wp64M3dRfkMiyTnAjcAUnYh+",
+	"oDwuX7sRYXBn5Ul5XL6BKd1TSHuqIlWE580U27c2D1WakF3VNKL7tjxx37uz8nUQDQQFypfutDz2l9Jc",
+	"eVyeuLEbuUs39t+joIgz9869dec0HEF57MZ+TGoZuTNwH8qX7sy9dRdu7P5GOnSj8g2Ux1C+8lvO3enk",
+	"we6SJLwqj/3m72kjTZGC/jw/2bj30o3dX8tXBPy78uvypPwGvG1oG8l+50+eeqWWJ9XkuRvXLevN9tZd",
+	"0AXnbkwz5UuSTBvHBNaL98Yrj8s/+OVLNypfzsS32KyC8hFOlLaN+jAW5LuHqE1Q+e1Wu9WmsFI5Sp4L",
+	"qkpa7dYqMTO3fe+zKz5O/GeuzIKa6ouQR/02n3ipMOBzVXIyKxOI7X02WU+mJz1CFqINjX2gksENTUKz",
+	"Obih9Egw1+ghsDWrC7waXus9qTzxTU8R5VpOyYCqFP+U+QRAEeADPPqJypp/tmq48h5KAhkmggxirMop",
+	"+cGhMMKGrJagjoBbyJSxcLsNt5rZjKhgp4/gqx+vjj6CKTJQXf+pVUHCsZcRTBb9iwqXZjc372dsGNVb",
+	"zM/a7U9ympuwBodcgGC7iGM0BqZeTDDu3HjzT97Trk+KmVDe3LpS9iwBBaBSQEaFqVEXGjqgv30dmKl+",
+	"V2r9+3xqY2tP6kntyf6QciHvGcqZquIetk+HKiJZeTEpwod0cw8XUMom12Zi6auk4dcmnJFzzTO0np+e",
+	"NKLhl+SzocxPptIELRG7sYhJ7jPVBBCbT/mBL67v7vf/Vz3w0ayiDY1Xrfb1DjipY2sbqsmlEO3/oIus",
+	"kEGUxevTz7oxBU4q1loOCkxFTUegd/IGLmfgJLbgsQl99qyZ9d2PiHFPkpyOUs+o3dUiNy0gXqzV73Ef",
+	"42eUQOh8uJ0anA5ObyEhE8JdbUPCB6a1J++nqTqqVueuVt05QVyG9QpPSEAGUmHInYWEqqSr1rdDZvL/",
+	"99QjZiuo8BNipma///aY+e3EMf4zY0XNt2CeulNFf/z1VDNwfhRf06HVf+ejQ1Ppg03ZPuqJd1fP8T3v",
+	"QWFQL/3YZDIjkEYT8ZdmQXxtC+B+uOLP3kv3h38fADKpO7/NFgAA",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
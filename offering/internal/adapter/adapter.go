@@ -3,6 +3,8 @@ package adapter
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/codes"
@@ -12,9 +14,17 @@ import (
 	"net/http"
 	"offering/internal/models"
 	"offering/internal/service"
+	"shared/auth"
+	"shared/problem"
 	"time"
 )
 
+// offerResponse оффер в ответе API (схема Offer)
+type offerResponse struct {
+	ID string `json:"id"`
+	*models.Order
+}
+
 type Adapter struct {
 	server        *http.Server
 	service       *service.Service
@@ -24,8 +34,8 @@ type Adapter struct {
 	ResponseTime  *prometheus.GaugeVec
 }
 
-func NewAdapter(logger *zap.Logger, tracer trace.Tracer, config *models.Config,
-	requestsTotal *prometheus.CounterVec, responseTime *prometheus.GaugeVec) *Adapter {
+func NewAdapter(logger *zap.Logger, tracer trace.Tracer, config *models.Config, authenticator *auth.Authenticator,
+	requestsTotal *prometheus.CounterVec, responseTime *prometheus.GaugeVec) (*Adapter, error) {
 	logger.Info("Creating adapter")
 
 	// Создание адаптера
@@ -38,10 +48,14 @@ func NewAdapter(logger *zap.Logger, tracer trace.Tracer, config *models.Config,
 		ResponseTime:  responseTime,
 	}
 
-	// Создание роутера и set путей
+	// Создание роутера и set путей из api/offering.yaml. Оффер создает и перезапрашивает клиент
+	// с bearer токеном, requote также вызывает client по сервисному токену. GET открыт: его вызывает
+	// trip по offer_id, который сам является подписанным токеном
 	router := chi.NewRouter()
-	router.Post("/offers", adapter.createOffer)
-	router.Get("/offers/{offerID}", adapter.getOffer)
+	err := adapter.routes(router, authenticator)
+	if err != nil {
+		return nil, err
+	}
 
 	// Заполнение сервера с созданным роутером
 	adapter.server = &http.Server{
@@ -51,11 +65,11 @@ func NewAdapter(logger *zap.Logger, tracer trace.Tracer, config *models.Config,
 
 	logger.Info("Adapter created")
 
-	return &adapter
+	return &adapter, nil
 }
 
 // createOffer обрабатывает запрос на создание заказа, возвращает созданный заказ
-func (a *Adapter) createOffer(w http.ResponseWriter, r *http.Request) {
+func (a *Adapter) CreateOffer(w http.ResponseWriter, r *http.Request) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("createOffer").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -71,7 +85,7 @@ func (a *Adapter) createOffer(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Body reading error")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Body reading error")
 		a.Logger.Sugar().Errorf("Body reading error. %v", err)
 		return
 	}
@@ -82,11 +96,27 @@ func (a *Adapter) createOffer(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Unmarshal body to order error")
-		w.WriteHeader(http.StatusBadRequest)
+		problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, "Incorrect order")
 		a.Logger.Sugar().Errorf("Unmarshal body to order error. %v", err)
 		return
 	}
 
+	if len(order.Waypoints) > service.MaxWaypoints {
+		span.SetStatus(codes.Error, "Too many waypoints")
+		problem.Write(w, http.StatusBadRequest, problem.CodeTooManyWaypoints,
+			fmt.Sprintf("At most %v waypoints are allowed", service.MaxWaypoints))
+		a.Logger.Sugar().Errorf("Too many waypoints. %v", len(order.Waypoints))
+		return
+	}
+
+	// client_id берется из токена, значение из тела игнорируется
+	clientID, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+	order.ClientID = clientID
+
 	// Создание заказа
 	order = a.service.CreateOffer(order)
 
@@ -95,27 +125,19 @@ func (a *Adapter) createOffer(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "JWT order error")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "JWT order error")
 		a.Logger.Sugar().Errorf("JWT order error. %v", err)
 		return
 	}
 
 	// Запись ответа
-	_, err = w.Write([]byte(jwtOffer))
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "Writing response error")
-		w.WriteHeader(http.StatusInternalServerError)
-		a.Logger.Sugar().Errorf("Writing response error. %v", err)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
+	a.writeOffer(w, span, jwtOffer, order)
 
 	a.Logger.Info("Order created")
 }
 
-// getOffer возвращает заказ при его наличии
-func (a *Adapter) getOffer(w http.ResponseWriter, r *http.Request) {
+// ParseOffer возвращает заказ при его наличии
+func (a *Adapter) ParseOffer(w http.ResponseWriter, r *http.Request, offerID string) {
 	// Статистика
 	startTime := time.Now()
 	defer a.ResponseTime.WithLabelValues("getOffer").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
@@ -126,41 +148,90 @@ func (a *Adapter) getOffer(w http.ResponseWriter, r *http.Request) {
 	ctx, span := a.Tracer.Start(r.Context(), "getOffer")
 	defer span.End()
 
-	// Чтение параметра из URL
-	offerID := chi.URLParam(r, "offerID")
-
 	// Извлечение информации из JWT-токена
 	order, err := a.service.UnJwtOffer(ctx, offerID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Order unjwt error")
-		w.WriteHeader(http.StatusBadRequest)
+		writeOfferError(w, err)
+		a.Logger.Sugar().Errorf("Order unjwt error. %v", err)
+		return
+	}
+
+	// Запись ответа
+	a.writeOffer(w, span, offerID, order)
+
+	a.Logger.Info("Offer got")
+}
+
+// RequoteOffer выпускает новый оффер вместо истекшего: client перезапрашивает оффер поездки на время
+func (a *Adapter) RequoteOffer(w http.ResponseWriter, r *http.Request, offerID string) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("requoteOffer").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("requoteOffer").Inc()
+	a.Logger.Info("Requoting offer")
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "requoteOffer")
+	defer span.End()
+
+	subject, ok := auth.UserID(r.Context())
+	if !ok {
+		problem.Write(w, http.StatusUnauthorized, problem.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Новый jwt-токен на тот же маршрут и клиента
+	order, jwtOffer, err := a.service.RequoteOffer(ctx, offerID, subject)
+	if errors.Is(err, service.ErrOfferWrongUser) {
+		span.SetStatus(codes.Error, "Offer of another client")
+		problem.Write(w, http.StatusForbidden, problem.CodeOfferWrongUser, "Offer belongs to another client")
+		a.Logger.Sugar().Errorf("Offer of another client. %v", subject)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Order unjwt error")
+		writeOfferError(w, err)
 		a.Logger.Sugar().Errorf("Order unjwt error. %v", err)
 		return
 	}
 
+	// Запись ответа
+	a.writeOffer(w, span, jwtOffer, order)
+
+	a.Logger.Info("Offer requoted")
+}
+
+// writeOffer отвечает оффером: подписанный токен в id и условия из него
+func (a *Adapter) writeOffer(w http.ResponseWriter, span trace.Span, offerID string, order *models.Order) {
 	// Сериализация Order в bytes
-	bytes, err := json.Marshal(order)
+	bytes, err := json.Marshal(offerResponse{ID: offerID, Order: order})
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Order marshal error")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, http.StatusInternalServerError, problem.CodeInternal, "Order marshal error")
 		a.Logger.Sugar().Errorf("Order marshal error. %v", err)
 		return
 	}
 
-	// Запись ответа
+	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(bytes)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Writing response error")
-		w.WriteHeader(http.StatusInternalServerError)
 		a.Logger.Sugar().Errorf("Writing response error. %v", err)
-		return
 	}
-	w.WriteHeader(http.StatusOK)
+}
 
-	a.Logger.Info("Offer got")
+// writeOfferError отвечает на ошибку чтения оффера: истекший оффер отличается от поддельного или поврежденного
+func writeOfferError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrOfferExpired) {
+		problem.Write(w, http.StatusBadRequest, problem.CodeOfferExpired, "Offer expired, request a new one")
+		return
+	}
+	problem.Write(w, http.StatusBadRequest, problem.CodeOfferInvalid, "Incorrect offer")
 }
 
 // Start запускает сервер
@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/go-chi/chi/v5"
+	middleware "github.com/oapi-codegen/nethttp-middleware"
+	"net/http"
+	"offering/api"
+	"shared/auth"
+	"shared/problem"
+)
+
+// Адаптер реализует интерфейс, сгенерированный по api/offering.yaml (go generate ./api).
+// Расхождение обработчиков с api.gen.go ломает сборку, а устаревший api.gen.go или маршрут вне
+// контракта ловит contract_test.go
+var _ api.ServerInterface = (*Adapter)(nil)
+
+// routes регистрирует операции контракта. Порядок обработки запроса: auth (только у операций
+// с bearerAuth в контракте), валидация по контракту, обработчик
+func (a *Adapter) routes(router chi.Router, authenticator *auth.Authenticator) error {
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return err
+	}
+	// Адрес сервиса задается конфигом, сверяются только пути
+	spec.Servers = nil
+
+	validate := middleware.OapiRequestValidatorWithOptions(spec, &middleware.Options{
+		Options: openapi3filter.Options{
+			// Токен уже проверен auth.Middleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+		ErrorHandler: func(w http.ResponseWriter, message string, statusCode int) {
+			problem.Write(w, statusCode, problem.CodeInvalidRequest, message)
+		},
+	})
+
+	api.HandlerWithOptions(a, api.ChiServerOptions{
+		BaseRouter: router,
+		// Последний middleware выполняется первым
+		Middlewares: []api.MiddlewareFunc{validate, authenticate(authenticator)},
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			problem.Write(w, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+		},
+	})
+	return nil
+}
+
+// authenticate проверяет bearer токен у операций, для которых контракт требует bearerAuth
+func authenticate(authenticator *auth.Authenticator) api.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		protected := authenticator.Middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Context().Value(api.BearerAuthScopes) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package adapter
+
+import (
+	"bytes"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"offering/api"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// specPath контракт, по которому сгенерирован api/api.gen.go
+const specPath = "../../api/offering.yaml"
+
+// TestEmbeddedSpecIsCurrent падает, если offering.yaml изменили, а api.gen.go не перегенерировали (go generate ./api)
+func TestEmbeddedSpecIsCurrent(t *testing.T) {
+	onDisk, err := openapi3.NewLoader().LoadFromFile(specPath)
+	if err != nil {
+		t.Fatalf("Loading %v: %v", specPath, err)
+	}
+	embedded, err := api.GetSwagger()
+	if err != nil {
+		t.Fatalf("Loading embedded spec: %v", err)
+	}
+	// Генератор встраивает operationId уже в виде имен методов ServerInterface
+	for _, item := range onDisk.Paths.Map() {
+		for _, operation := range item.Operations() {
+			operation.OperationID = exported(operation.OperationID)
+		}
+	}
+
+	want, err := onDisk.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := embedded.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("api/api.gen.go is stale, run go generate ./api")
+	}
+}
+
+// TestRoutesMatchContract сверяет маршруты роутера с операциями контракта в обе стороны
+func TestRoutesMatchContract(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromFile(specPath)
+	if err != nil {
+		t.Fatalf("Loading %v: %v", specPath, err)
+	}
+	operations := map[string]bool{}
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			operations[method+" "+path] = true
+		}
+	}
+
+	// Middleware-ы только оборачивают обработчики, зависимости адаптера для обхода роутера не нужны
+	router := chi.NewRouter()
+	err = (&Adapter{}).routes(router, nil)
+	if err != nil {
+		t.Fatalf("Registering routes: %v", err)
+	}
+	routes := map[string]bool{}
+	err = chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[method+" "+strings.TrimSuffix(route, "/")] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walking routes: %v", err)
+	}
+
+	for _, operation := range difference(operations, routes) {
+		t.Errorf("Operation %v from the contract has no route", operation)
+	}
+	for _, route := range difference(routes, operations) {
+		t.Errorf("Route %v is not in the contract", route)
+	}
+}
+
+// exported имя с заглавной первой буквой
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// difference ключи a, которых нет в b, по порядку
+func difference(a map[string]bool, b map[string]bool) []string {
+	var missing []string
+	for key := range a {
+		if !b[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
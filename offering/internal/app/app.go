@@ -18,6 +18,7 @@ import (
 	"offering/internal/adapter"
 	"offering/internal/models"
 	"os"
+	"shared/auth"
 )
 
 const configPath = "./config/config.json"
@@ -62,15 +63,32 @@ func NewApp() *App {
 	tracer := otel.Tracer("final")
 	sugLog.Info("Tracer created")
 
+	// Ключи проверки bearer токенов клиентов
+	sugLog.Info("Loading JWKS")
+	keys, err := auth.NewKeySet(context.Background(), config.JWKSPath, config.JWKSURL)
+	if err != nil {
+		sugLog.Fatalf("JWKS init error. %v", err)
+		return nil
+	}
+	authenticator := auth.NewAuthenticator(keys, config.JWTIssuer, config.JWTAudience)
+	sugLog.Info("JWKS loaded")
+
 	// Prometheus
 	sugLog.Info("Initializing Prometheus")
 	requestsTotal, responseTime := initPrometheus()
 	sugLog.Info("Prometheus initialized")
 
+	// HTTP адаптер по контракту api/offering.yaml
+	offerAdapter, err := adapter.NewAdapter(logger, tracer, config, authenticator, requestsTotal, responseTime)
+	if err != nil {
+		sugLog.Fatalf("Adapter init error. %v", err)
+		return nil
+	}
+
 	// Создание объекта App
 	sugLog.Info("Creating app")
 	app := App{
-		Adapter: adapter.NewAdapter(logger, tracer, config, requestsTotal, responseTime),
+		Adapter: offerAdapter,
 		Logger:  logger,
 		Tracer:  tracer,
 		Config:  config,
@@ -13,13 +13,28 @@ type Price struct {
 }
 
 type Order struct {
-	From     Location `json:"from"`
-	To       Location `json:"to"`
-	ClientID string   `json:"client_id"`
-	Price    Price    `json:"price"`
+	From      Location   `json:"from"`
+	To        Location   `json:"to"`
+	Waypoints []Location `json:"waypoints,omitempty"`
+	ClientID  string     `json:"client_id"`
+	Price     Price      `json:"price"`
+}
+
+// Route точки маршрута по порядку: from, промежуточные остановки, to
+func (o *Order) Route() []Location {
+	route := make([]Location, 0, len(o.Waypoints)+2)
+	route = append(route, o.From)
+	route = append(route, o.Waypoints...)
+	return append(route, o.To)
 }
 
 type Config struct {
 	PrivateKey    rsa.PrivateKey `json:"privateKey"`
 	JaegerAddress string         `json:"jaegerAddress"`
+	JWKSPath      string         `json:"jwksPath"`
+	JWKSURL       string         `json:"jwksURL"`
+	JWTIssuer     string         `json:"jwtIssuer"`
+	JWTAudience   string         `json:"jwtAudience"`
+	// ServiceSubjects sub сервисных токенов, которым можно перезапрашивать офферы любых клиентов
+	ServiceSubjects []string `json:"serviceSubjects"`
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"go.opentelemetry.io/otel/codes"
@@ -10,9 +11,20 @@ import (
 	"go.uber.org/zap"
 	"math"
 	"offering/internal/models"
+	"slices"
 	"time"
 )
 
+// ErrOfferExpired срок действия оффера истек, нужен новый оффер
+var ErrOfferExpired = errors.New("offer expired")
+
+// ErrOfferWrongUser оффер перезапрашивает не его клиент и не доверенный сервис
+var ErrOfferWrongUser = errors.New("offer belongs to another client")
+
+// MaxRequoteAge сколько после exp оффер еще можно перезапросить. Совпадает с тем, насколько вперед
+// client позволяет бронировать поездку (reservation.MaxAhead)
+const MaxRequoteAge = 30 * 24 * time.Hour
+
 type Service struct {
 	Logger *zap.Logger
 	Tracer trace.Tracer
@@ -38,18 +50,32 @@ func hashString(input string) int {
 	return hash
 }
 
-// CreateOffer создает оффер
+// MaxWaypoints максимальное число промежуточных остановок
+const MaxWaypoints = 10
+
+// CreateOffer создает оффер. Стоимость маршрута - сумма стоимостей его отрезков
 func (s *Service) CreateOffer(order *models.Order) *models.Order {
+	route := order.Route()
+	amount := 0.0
+	for i := 1; i < len(route); i++ {
+		amount += segmentPrice(route[i-1], route[i], order.ClientID)
+	}
+
 	order.Price = models.Price{
-		Amount: math.Sqrt(
-			(order.From.Lat-order.To.Lat)*(order.From.Lat-order.To.Lat) +
-				(order.From.Lng-order.To.Lng)*(order.From.Lng-order.To.Lng)*float64(hashString(order.ClientID)),
-		),
+		Amount:   amount,
 		Currency: "RUB",
 	}
 	return order
 }
 
+// segmentPrice стоимость отрезка маршрута
+func segmentPrice(from models.Location, to models.Location, clientID string) float64 {
+	return math.Sqrt(
+		(from.Lat-to.Lat)*(from.Lat-to.Lat) +
+			(from.Lng-to.Lng)*(from.Lng-to.Lng)*float64(hashString(clientID)),
+	)
+}
+
 // JwtOffer превращает order в jwt-токен
 func (s *Service) JwtOffer(ctx context.Context, order *models.Order) (string, error) {
 	ctx, span := s.Tracer.Start(ctx, "jwt")
@@ -81,13 +107,44 @@ func (s *Service) JwtOffer(ctx context.Context, order *models.Order) (string, er
 }
 
 func (s *Service) UnJwtOffer(ctx context.Context, tokenString string) (*models.Order, error) {
+	return s.unJwtOffer(ctx, tokenString)
+}
+
+// RequoteOffer выпускает новый оффер на маршрут и клиента из offer с истекшим сроком.
+// Подпись проверяется как обычно, exp допускается не старше MaxRequoteAge. Перезапросить оффер
+// может его клиент или сервис из Config.ServiceSubjects
+func (s *Service) RequoteOffer(ctx context.Context, tokenString string, subject string) (*models.Order, string, error) {
+	order, err := s.unJwtOffer(ctx, tokenString, jwt.WithExpirationRequired(), jwt.WithLeeway(MaxRequoteAge))
+	if err != nil {
+		return nil, "", err
+	}
+	if subject != order.ClientID && !slices.Contains(s.Config.ServiceSubjects, subject) {
+		return nil, "", ErrOfferWrongUser
+	}
+
+	order = s.CreateOffer(order)
+	jwtOffer, err := s.JwtOffer(ctx, order)
+	if err != nil {
+		return nil, "", err
+	}
+	return order, jwtOffer, nil
+}
+
+// unJwtOffer проверяет токен оффера и извлекает из него заказ
+func (s *Service) unJwtOffer(ctx context.Context, tokenString string, options ...jwt.ParserOption) (*models.Order, error) {
 	ctx, span := s.Tracer.Start(ctx, "unjwt")
 	defer span.End()
 
 	// Проверка и извлечение данных из токена
+	options = append(options, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return &s.Config.PrivateKey.PublicKey, nil
-	})
+	}, options...)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Token expired error")
+		return nil, fmt.Errorf("%w: %v", ErrOfferExpired, err)
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Token read error")
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"shared/problem"
+	"strings"
+)
+
+// contextKey ключ identity в контексте запроса
+type contextKey struct{}
+
+// validMethods алгоритмы подписи, которые принимаются. "none" и HMAC исключены
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Authenticator проверяет bearer токены, подписанные ключами из KeySet
+type Authenticator struct {
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+// NewAuthenticator создает Authenticator. Пустые issuer и audience не проверяются
+func NewAuthenticator(keys *KeySet, issuer string, audience string) *Authenticator {
+	return &Authenticator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// Middleware пропускает только запросы с валидным токеном в заголовке Authorization: Bearer
+// и кладет subject токена в контекст запроса (см. UserID)
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := BearerToken(r)
+		if token == "" {
+			unauthorized(w, "", "Missing bearer token")
+			return
+		}
+
+		userID, err := a.Authenticate(r.Context(), token)
+		if err != nil {
+			unauthorized(w, problem.CodeInvalidToken, "Invalid token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), userID)))
+	})
+}
+
+// Authenticate проверяет подпись и claims токена и возвращает его subject
+func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (string, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(validMethods),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		options = append(options, jwt.WithAudience(a.audience))
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	}, options...)
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", errors.New("token has no subject")
+	}
+
+	return claims.Subject, nil
+}
+
+// BearerToken достает токен из заголовка Authorization или, для WebSocket и EventSource,
+// которые не умеют ставить заголовки, из query-параметра access_token (RFC 6750)
+func BearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// WithUserID возвращает контекст с identity пользователя
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserID возвращает identity пользователя, проверенную Middleware
+func UserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(contextKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// unauthorized отвечает 401 с заголовком WWW-Authenticate (RFC 6750). Код ошибки
+// в заголовке совпадает с кодом в теле problem+json
+func unauthorized(w http.ResponseWriter, code string, message string) {
+	challenge := `Bearer`
+	if code != "" {
+		challenge += ` error="` + code + `"`
+	} else {
+		code = problem.CodeUnauthorized
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	problem.Write(w, http.StatusUnauthorized, code, message)
+}
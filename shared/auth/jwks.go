@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshInterval как часто ключи перечитываются из JWKS
+	refreshInterval = 10 * time.Minute
+	// minRefreshInterval не чаще этого JWKS перечитывается из-за неизвестного kid
+	minRefreshInterval = time.Minute
+	fetchTimeout       = 10 * time.Second
+)
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// jwk ключ в формате RFC 7517. Поддерживаются RSA, EC (P-256, P-384, P-521) и OKP (Ed25519)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet публичные ключи проверки подписи из локального JWKS файла или JWKS endpoint-а
+type KeySet struct {
+	path   string
+	url    string
+	client *http.Client
+
+	mutex     sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	refreshed time.Time
+}
+
+// NewKeySet читает JWKS из файла path или, если он пуст, по адресу url
+func NewKeySet(ctx context.Context, path string, url string) (*KeySet, error) {
+	if path == "" && url == "" {
+		return nil, errors.New("JWKS path or URL must be set")
+	}
+
+	keySet := &KeySet{
+		path:   path,
+		url:    url,
+		client: &http.Client{Timeout: fetchTimeout},
+	}
+	err := keySet.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Key возвращает ключ по kid. Пустой kid допустим, если в наборе ровно один ключ.
+// Устаревший набор и неизвестный kid приводят к повторному чтению JWKS (ротация ключей)
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, refreshed, ok := k.lookup(kid)
+	since := time.Since(refreshed)
+	if (ok && since < refreshInterval) || (!ok && since < minRefreshInterval) {
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		return key, nil
+	}
+
+	err := k.refresh(ctx)
+	if err != nil {
+		// Старые ключи лучше, чем отказ всем пользователям
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, _, ok = k.lookup(kid)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// lookup ищет ключ под read lock
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, time.Time, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, k.refreshed, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, k.refreshed, ok
+}
+
+// refresh перечитывает JWKS
+func (k *KeySet) refresh(ctx context.Context) error {
+	bytes, err := k.read(ctx)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	// Время ставится и при ошибке, чтобы не долбить недоступный endpoint
+	k.refreshed = time.Now()
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(bytes)
+	if err != nil {
+		return err
+	}
+	k.keys = keys
+	return nil
+}
+
+// read читает JWKS из файла или по HTTP
+func (k *KeySet) read(ctx context.Context) ([]byte, error) {
+	if k.path != "" {
+		return os.ReadFile(k.path)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := k.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned %v", response.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, 1<<20))
+}
+
+// parseJWKS разбирает набор ключей, ключи шифрования и неподдерживаемых типов пропускаются
+func parseJWKS(bytes []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := json.Unmarshal(bytes, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWK %q: %w", key.Kid, err)
+		}
+		if publicKey != nil {
+			keys[key.Kid] = publicKey
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey строит ключ из параметров JWK, nil - неподдерживаемый тип
+func (j *jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("incorrect Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// decodeInt декодирует base64url big-endian число
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,64 @@
+package cancellation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrForbidden поездку нельзя отменить в текущем статусе: она уже началась или завершилась
+var ErrForbidden = errors.New("trip can not be canceled")
+
+// ErrFeeChanged штраф по текущему статусу поездки не совпадает со штрафом из команды отмены
+var ErrFeeChanged = errors.New("cancellation fee changed")
+
+// forbidden статусы, в которых отмена запрещена независимо от правил
+var forbidden = map[string]bool{
+	"STARTED":  true,
+	"ENDED":    true,
+	"CANCELED": true,
+}
+
+// Rule штраф за отмену в одном статусе: Fixed плюс Percent процентов цены поездки,
+// не больше Max (0 - без ограничения). Сумма в валюте цены поездки
+type Rule struct {
+	Fixed   float64 `json:"fixed"`
+	Percent float64 `json:"percent"`
+	Max     float64 `json:"max"`
+}
+
+// Policy правила штрафа по статусам поездки (config cancellationPolicy).
+// Статус без правила, например DRIVER_SEARCH, отменяется бесплатно.
+// client считает штраф по статусу из проекции и передает его в команде отмены, trip пересчитывает
+// его по статусу из своей таблицы trips и отклоняет отмену, если штраф изменился
+type Policy struct {
+	rules map[string]Rule
+}
+
+func NewPolicy(rules map[string]Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// Fee возвращает штраф за отмену поездки ценой price в статусе status. 0 - отмена бесплатна
+func (p *Policy) Fee(status string, price float64) (float64, error) {
+	if forbidden[status] {
+		return 0, fmt.Errorf("%w in status %v", ErrForbidden, status)
+	}
+
+	rule, ok := p.rules[status]
+	if !ok {
+		return 0, nil
+	}
+
+	amount := rule.Fixed + price*rule.Percent/100
+	if rule.Max > 0 && amount > rule.Max {
+		amount = rule.Max
+	}
+	// Копейки
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 {
+		return 0, nil
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,70 @@
+package cancellation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyFee(t *testing.T) {
+	policy := NewPolicy(map[string]Rule{
+		"DRIVER_FOUND": {Fixed: 50, Percent: 5, Max: 300},
+		"ON_POSITION":  {Fixed: 100, Percent: 10, Max: 500},
+	})
+
+	tests := []struct {
+		name   string
+		status string
+		price  float64
+		want   float64
+		err    error
+	}{
+		{"driver search is free", "DRIVER_SEARCH", 1000, 0, nil},
+		{"driver found", "DRIVER_FOUND", 1000, 100, nil},
+		{"driver found free trip", "DRIVER_FOUND", 0, 50, nil},
+		{"driver found just below max", "DRIVER_FOUND", 4999, 299.95, nil},
+		{"driver found at max", "DRIVER_FOUND", 5000, 300, nil},
+		{"driver found above max", "DRIVER_FOUND", 100000, 300, nil},
+		{"on position", "ON_POSITION", 1000, 200, nil},
+		{"on position at max", "ON_POSITION", 4000, 500, nil},
+		{"rounded to cents", "ON_POSITION", 0.123, 100.01, nil},
+		{"started", "STARTED", 1000, 0, ErrForbidden},
+		{"ended", "ENDED", 1000, 0, ErrForbidden},
+		{"canceled", "CANCELED", 1000, 0, ErrForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := policy.Fee(test.status, test.price)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Fee(%q, %v) error = %v, want %v", test.status, test.price, err, test.err)
+			}
+			if got != test.want {
+				t.Fatalf("Fee(%q, %v) = %v, want %v", test.status, test.price, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFeeWithoutMax(t *testing.T) {
+	policy := NewPolicy(map[string]Rule{"DRIVER_FOUND": {Percent: 10}})
+
+	got, err := policy.Fee("DRIVER_FOUND", 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1e5 {
+		t.Fatalf("Fee = %v, want %v", got, 1e5)
+	}
+}
+
+func TestPolicyFeeNegativeRule(t *testing.T) {
+	policy := NewPolicy(map[string]Rule{"DRIVER_FOUND": {Fixed: -10}})
+
+	got, err := policy.Fee("DRIVER_FOUND", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Fatalf("Fee = %v, want 0", got)
+	}
+}
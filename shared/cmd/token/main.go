@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Выпуск bearer токенов для локальной разработки. Ключ в репозитории не хранится:
+//
+//	go run ./cmd/token -keygen ../dev-keys
+//
+// создает jwt-dev.pem и jwks.json, которые docker-compose.dev.yaml монтирует в client и offering.
+// Запуск из модуля shared: go run ./cmd/token -key ../dev-keys/jwt-dev.pem -sub <user id>
+func main() {
+	keygen := flag.String("keygen", "", "directory to write a new jwt-dev.pem and jwks.json to")
+	subject := flag.String("sub", "", "user id")
+	keyPath := flag.String("key", "", "PKCS #8 RSA private key")
+	kid := flag.String("kid", "dev", "key id from JWKS")
+	issuer := flag.String("iss", "", "issuer, if services check it")
+	audience := flag.String("aud", "", "audience, if services check it")
+	ttl := flag.Duration("ttl", 24*time.Hour, "token lifetime")
+	flag.Parse()
+
+	if *keygen != "" {
+		err := generateKey(*keygen, *kid)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *subject == "" || *keyPath == "" {
+		log.Fatal("usage: token -key <jwt-dev.pem> -sub <user id> [-ttl 24h] | token -keygen <dir>")
+	}
+
+	bytes, err := os.ReadFile(*keyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		log.Fatal("no PEM block in key file")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   *subject,
+		Issuer:    *issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(*ttl)),
+	}
+	if *audience != "" {
+		claims.Audience = jwt.ClaimStrings{*audience}
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = *kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(signed)
+}
+
+// generateKey создает RSA ключ и записывает в dir приватный ключ и JWKS с его публичной частью
+func generateKey(dir string, kid string) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	jwks, err := json.MarshalIndent(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, "jwt-dev.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "jwks.json"), append(jwks, '\n'), 0o644)
+}
@@ -0,0 +1,105 @@
+package problem
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// ContentType тип ответа с ошибкой (RFC 7807)
+const ContentType = "application/problem+json"
+
+// typePrefix префикс поля type, за ним идет код ошибки
+const typePrefix = "urn:problem:"
+
+// Коды ошибок. Код стабилен и не зависит от текста detail: по нему приложение и выбирает реакцию
+const (
+	// Общие
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeInvalidToken   = "invalid_token"
+	CodeInternal       = "internal_error"
+
+	// Офферы
+	CodeOfferInvalid     = "offer_invalid"
+	CodeOfferExpired     = "offer_expired"
+	CodeOfferWrongUser   = "offer_wrong_user"
+	CodeTooManyWaypoints = "too_many_waypoints"
+	// CodeOfferingUnavailable offering не ответил или ответил не по контракту
+	CodeOfferingUnavailable = "offering_unavailable"
+
+	// Поездки и брони
+	CodeTripNotFound             = "trip_not_found"
+	CodeTripNotCancelable        = "trip_not_cancelable"
+	CodeInvalidScheduledAt       = "invalid_scheduled_at"
+	CodeReservationNotFound      = "reservation_not_found"
+	CodeReservationNotCancelable = "reservation_not_cancelable"
+
+	// Места и webhook-и
+	CodePlaceNotFound   = "place_not_found"
+	CodePlaceExists     = "place_exists"
+	CodeWebhookNotFound = "webhook_not_found"
+
+	// Idempotency-Key
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+)
+
+// Problem тело ответа с ошибкой. Code дублирует окончание Type, чтобы его не приходилось разбирать
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// New создает Problem с кодом code. Title - стандартный текст статуса
+func New(status int, code string, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	return p.Code + ": " + p.Detail
+}
+
+// Write отвечает ошибкой status с кодом code
+func Write(w http.ResponseWriter, status int, code string, detail string) {
+	New(status, code, detail).Write(w)
+}
+
+// Write отвечает ошибкой p
+func (p *Problem) Write(w http.ResponseWriter) {
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(bytes)
+}
+
+// Parse читает ошибку из ответа другого сервиса. Возвращает nil, если ответ не problem+json
+func Parse(response *http.Response, body []byte) *Problem {
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil || mediaType != ContentType {
+		return nil
+	}
+
+	var p Problem
+	err = json.Unmarshal(body, &p)
+	if err != nil || p.Code == "" {
+		return nil
+	}
+	p.Status = response.StatusCode
+	return &p
+}
@@ -0,0 +1,149 @@
+// Утилита dlq просматривает DLQ сервисов и возвращает сообщения в исходные топики.
+// Запуск из модуля trip: go run ./cmd/dlq list -topic trip-dlq-topic
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"trip/internal/models"
+	"trip/pkg/kafka"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const usage = `usage:
+  dlq list -topic trip-dlq-topic [-address kafka:9092]
+  dlq redrive -topic trip-dlq-topic (-partition P -offset N | -all) [-address kafka:9092]`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	address := flags.String("address", "kafka:9092", "Kafka address")
+	topic := flags.String("topic", "", "DLQ topic (trip-dlq-topic, client-dlq-topic)")
+	partition := flags.Int("partition", 0, "partition of the message to re-drive")
+	offset := flags.Int64("offset", -1, "offset of the message to re-drive")
+	all := flags.Bool("all", false, "re-drive every message in the DLQ")
+	_ = flags.Parse(os.Args[2:])
+	if *topic == "" {
+		log.Fatal(usage)
+	}
+
+	// Чтение всех сообщений DLQ
+	letters, err := readAll(*address, *topic)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch command {
+	case "list":
+		for _, letter := range letters {
+			fmt.Printf("partition=%v offset=%v service=%v stage=%v attempts=%v failed_at=%v source=%v\n  error: %v\n  payload: %s\n",
+				letter.partition, letter.offset, letter.Service, letter.Stage, letter.Attempts, letter.FailedAt.Format(time.RFC3339),
+				letter.SourceTopic, letter.Error, letter.Payload)
+		}
+	case "redrive":
+		if !*all && *offset < 0 {
+			log.Fatal(usage)
+		}
+		redriven := 0
+		for _, letter := range letters {
+			if !*all && (letter.partition != *partition || letter.offset != *offset) {
+				continue
+			}
+			err = redrive(*address, &letter.DeadLetter)
+			if err != nil {
+				log.Fatalf("Re-drive of %v:%v failed. %v", letter.partition, letter.offset, err)
+			}
+			redriven++
+		}
+		log.Printf("Re-driven %v messages", redriven)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// storedLetter сообщение DLQ с его положением в топике
+type storedLetter struct {
+	models.DeadLetter
+	partition int
+	offset    int64
+}
+
+// readAll читает все партиции DLQ от первого до последнего offset
+func readAll(address string, topic string) ([]storedLetter, error) {
+	conn, err := kafkago.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []storedLetter
+	for _, partition := range partitions {
+		read, err := readPartition(address, topic, partition.ID)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, read...)
+	}
+
+	return letters, nil
+}
+
+// readPartition читает одну партицию DLQ
+func readPartition(address string, topic string, partition int) ([]storedLetter, error) {
+	conn, err := kafkago.DialLeader(context.Background(), "tcp", address, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Seek(first, kafkago.SeekAbsolute)
+	if err != nil {
+		return nil, err
+	}
+
+	var letters []storedLetter
+	for offset := first; offset < last; {
+		message, err := conn.ReadMessage(10e6)
+		if err != nil {
+			return nil, err
+		}
+		offset = message.Offset + 1
+
+		var letter models.DeadLetter
+		err = json.Unmarshal(message.Value, &letter)
+		if err != nil {
+			log.Printf("Skipping %v:%v, not a dead letter. %v", partition, message.Offset, err)
+			continue
+		}
+		letters = append(letters, storedLetter{DeadLetter: letter, partition: partition, offset: message.Offset})
+	}
+
+	return letters, nil
+}
+
+// redrive отправляет исходный payload с исходным ключом обратно в топик, из которого он был прочитан
+func redrive(address string, letter *models.DeadLetter) error {
+	writer := kafka.NewWriter(address, letter.SourceTopic)
+	defer writer.Close()
+
+	return kafka.SendToTopic(context.Background(), writer, letter.Key, letter.Payload)
+}
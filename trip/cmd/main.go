@@ -2,13 +2,36 @@ package main
 
 import (
 	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"trip/internal/app"
 )
 
+// shutdownTimeout время на закрытие соединений и отправку span-ов после остановки
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	ctx := context.Background()
+	// Отмена контекста по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Подкоманда migrate: управление схемой без запуска сервиса
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := app.Migrate(ctx, os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	newApp := app.NewApp(ctx)
 
 	newApp.Start(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	newApp.Shutdown(shutdownCtx)
 }
@@ -30,4 +30,7 @@ require (
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/sys v0.14.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
+	shared v0.0.0
 )
+
+replace shared => ../shared
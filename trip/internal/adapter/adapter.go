@@ -0,0 +1,254 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+	"trip/internal/models"
+	"trip/internal/repository"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Adapter HTTP API для чтения состояния и истории поездок
+type Adapter struct {
+	server        *http.Server
+	repository    *repository.Repository
+	Logger        *zap.Logger
+	Tracer        trace.Tracer
+	RequestsTotal *prometheus.CounterVec
+	ResponseTime  *prometheus.GaugeVec
+}
+
+func NewAdapter(logger *zap.Logger, tracer trace.Tracer, config *models.Config, repository *repository.Repository,
+	requestsTotal *prometheus.CounterVec, responseTime *prometheus.GaugeVec) *Adapter {
+	logger.Info("Creating adapter")
+
+	// Создание адаптера
+	adapter := Adapter{
+		server:        nil, // будет заполнен ниже
+		repository:    repository,
+		Logger:        logger,
+		Tracer:        tracer,
+		RequestsTotal: requestsTotal,
+		ResponseTime:  responseTime,
+	}
+
+	// Создание роутера и set путей
+	router := chi.NewRouter()
+	router.Get("/trips", adapter.listTrips)
+	router.Get("/trips/{tripID}", adapter.getTrip)
+	router.Get("/trips/{tripID}/events", adapter.listEvents)
+
+	// Заполнение сервера с созданным роутером
+	adapter.server = &http.Server{
+		Addr:    config.ServeAddress,
+		Handler: router,
+	}
+
+	logger.Info("Adapter created")
+
+	return &adapter
+}
+
+// getTrip возвращает текущее состояние поездки
+func (a *Adapter) getTrip(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("getTrip").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("getTrip").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "getTrip")
+	defer span.End()
+
+	// Чтение параметра из URL
+	tripID := chi.URLParam(r, "tripID")
+
+	// Получение поездки
+	trip, err := a.repository.GetTrip(ctx, tripID)
+	if errors.Is(err, repository.ErrTripNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Trip not found")
+		http.Error(w, "Trip not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres read error")
+		http.Error(w, "Postgres read error", http.StatusInternalServerError)
+		a.Logger.Sugar().Errorf("Postgres read error. %v", err)
+		return
+	}
+
+	a.writeJSON(w, span, trip)
+}
+
+// listEvents возвращает историю поездки
+func (a *Adapter) listEvents(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("listEvents").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("listEvents").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "listEvents")
+	defer span.End()
+
+	// Чтение параметра из URL
+	tripID := chi.URLParam(r, "tripID")
+
+	// Получение истории
+	events, err := a.repository.ListEvents(ctx, tripID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres read error")
+		http.Error(w, "Postgres read error", http.StatusInternalServerError)
+		a.Logger.Sugar().Errorf("Postgres read error. %v", err)
+		return
+	}
+	if len(events) == 0 {
+		span.SetStatus(codes.Error, "Trip not found")
+		http.Error(w, "Trip not found", http.StatusNotFound)
+		return
+	}
+
+	a.writeJSON(w, span, events)
+}
+
+// listTrips возвращает поездки с фильтрацией по status, driver_id и периоду создания [from, to)
+func (a *Adapter) listTrips(w http.ResponseWriter, r *http.Request) {
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues("listTrips").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues("listTrips").Inc()
+
+	// Старт span-а трейсера
+	ctx, span := a.Tracer.Start(r.Context(), "listTrips")
+	defer span.End()
+
+	// Чтение фильтра из query
+	filter, err := parseFilter(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Получение поездок
+	trips, err := a.repository.ListTrips(ctx, filter)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres read error")
+		http.Error(w, "Postgres read error", http.StatusInternalServerError)
+		a.Logger.Sugar().Errorf("Postgres read error. %v", err)
+		return
+	}
+
+	a.writeJSON(w, span, trips)
+}
+
+// parseFilter разбирает query-параметры status, driver_id, from, to (RFC 3339), limit и offset
+func parseFilter(r *http.Request) (*models.TripFilter, error) {
+	query := r.URL.Query()
+	filter := models.TripFilter{
+		Status:   query.Get("status"),
+		DriverId: query.Get("driver_id"),
+		Limit:    defaultLimit,
+	}
+
+	var err error
+	if value := query.Get("from"); value != "" {
+		filter.From, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid from, expected RFC 3339 time")
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		filter.To, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid to, expected RFC 3339 time")
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxLimit {
+			return nil, errors.New("invalid limit, expected 1.." + strconv.Itoa(maxLimit))
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		filter.Offset, err = strconv.Atoi(value)
+		if err != nil || filter.Offset < 0 {
+			return nil, errors.New("invalid offset")
+		}
+	}
+
+	return &filter, nil
+}
+
+// writeJSON сериализует value в ответ
+func (a *Adapter) writeJSON(w http.ResponseWriter, span trace.Span, value interface{}) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Marshal error")
+		http.Error(w, "Marshal error", http.StatusInternalServerError)
+		a.Logger.Sugar().Errorf("Marshal error. %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(bytes)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Writing response error")
+		a.Logger.Sugar().Errorf("Writing response error. %v", err)
+	}
+}
+
+// Start запускает сервер
+func (a *Adapter) Start(ctx context.Context) error {
+	a.Logger.Info("Starting adapter")
+
+	// Канал, сообщающий о завершении работы сервера
+	finish := make(chan error, 1)
+
+	// Запуск сервера
+	go func() {
+		err := a.server.ListenAndServe()
+		finish <- err
+	}()
+
+	// Поддержка завершения с контекстом
+	var err error
+	select {
+	case err = <-finish:
+		a.Logger.Info("Adapter stopped")
+	case <-ctx.Done():
+		// Дожидаемся завершения текущих запросов
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = a.server.Shutdown(shutdownCtx)
+		a.Logger.Info("Adapter stopped because ctx")
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		a.Logger.Sugar().Errorf("Server error. %v", err)
+	}
+
+	return err
+}
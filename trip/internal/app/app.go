@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
@@ -22,21 +23,73 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"shared/cancellation"
+	"shared/problem"
+	"strconv"
+	"sync"
 	"time"
+	"trip/internal/adapter"
+	"trip/internal/migrate"
 	"trip/internal/models"
+	"trip/internal/outbox"
+	"trip/internal/repository"
+	"trip/internal/state"
+	"trip/migrations"
 	kfk "trip/pkg/kafka"
 )
 
 const configPath = "./config/config.json"
 
+// Топики Kafka
+const (
+	ToClientTopic         = "trip-client-topic"
+	ToDriverTopic         = "trip-driver-topic"
+	FromClientDriverTopic = "driver-client-trip-topic"
+	DeadLetterTopic       = "trip-dlq-topic"
+)
+
+// ConsumerGroup группа, в которой реплики trip делят партиции топика команд
+const ConsumerGroup = "trip"
+
+// Повторы обработки при временных ошибках (база, offering)
+const (
+	maxAttempts = 3
+	retryDelay  = time.Second
+)
+
+// Этапы обработки, на которых сообщение может попасть в DLQ
+const (
+	stageUnmarshal   = "unmarshal"
+	stageContentType = "content_type"
+	stageData        = "data_unmarshal"
+	stageUnknownType = "unknown_type"
+	stageOffer       = "offer"
+	stageDBRead      = "db_read"
+	stageDBWrite     = "db_write"
+	stageMarshal     = "marshal"
+)
+
+// failure ошибка обработки сообщения с этапом, на котором она произошла
+type failure struct {
+	stage     string
+	err       error
+	retryable bool
+}
+
 type App struct {
-	ToClientTopic         *kafka.Conn
-	ToDriverTopic         *kafka.Conn
-	FromClientDriverTopic *kafka.Conn
+	ToClientTopic         *kafka.Writer
+	ToDriverTopic         *kafka.Writer
+	FromClientDriverTopic *kafka.Reader
+	DeadLetterTopic       *kafka.Writer
 	Config                *models.Config
 	Logger                *zap.Logger
 	Tracer                trace.Tracer
+	TracerProvider        *tracesdk.TracerProvider
 	Postgres              *sql.DB
+	Repository            *repository.Repository
+	Adapter               *adapter.Adapter
+	Relay                 *outbox.Relay
+	Cancellation          *cancellation.Policy
 	RequestsTotal         *prometheus.CounterVec
 	ResponseTime          *prometheus.GaugeVec
 }
@@ -61,7 +114,7 @@ func NewApp(ctx context.Context) *App {
 
 	// Инициализация Jaeger
 	sugLog.Info("Initializing Jaeger")
-	err = initJaeger(config.JaegerAddress)
+	tracerProvider, err := initJaeger(config.JaegerAddress)
 	if err != nil {
 		sugLog.Fatalf("Jaeger init error. %v", err)
 		return nil
@@ -86,33 +139,54 @@ func NewApp(ctx context.Context) *App {
 	}
 	sugLog.Info("Postgres connected")
 
-	// Подключение к Kafka
-	connClient, err := kfk.ConnectKafka(ctx, config.KafkaAddress, "trip-client-topic", 0)
+	// Применение миграций
+	sugLog.Info("Applying migrations")
+	migrator, err := migrate.NewMigrator(postgres, migrations.FS)
 	if err != nil {
-		sugLog.Fatalf("Kafka connect error. %v", err)
+		sugLog.Fatalf("Migrations read error. %v", err)
 		return nil
 	}
-	connDriver, err := kfk.ConnectKafka(ctx, config.KafkaAddress, "trip-driver-topic", 0)
+	applied, err := migrator.Up(ctx)
 	if err != nil {
-		sugLog.Fatalf("Kafka connect error. %v", err)
+		sugLog.Fatalf("Migrations apply error. %v", err)
 		return nil
 	}
-	connClDrv, err := kfk.ConnectKafka(ctx, config.KafkaAddress, "driver-client-trip-topic", 0)
+	sugLog.Infof("Migrations applied: %v", applied)
+
+	// Подключение к Kafka
+	sugLog.Info("Creating Kafka topics")
+	err = kfk.CreateTopics(config.KafkaAddress, config.KafkaPartitions,
+		ToClientTopic, ToDriverTopic, FromClientDriverTopic, DeadLetterTopic)
 	if err != nil {
-		sugLog.Fatalf("Kafka connect error. %v", err)
+		sugLog.Fatalf("Kafka topics create error. %v", err)
 		return nil
 	}
+	connClient := kfk.NewWriter(config.KafkaAddress, ToClientTopic)
+	connDriver := kfk.NewWriter(config.KafkaAddress, ToDriverTopic)
+	connClDrv := kfk.NewReader(config.KafkaAddress, FromClientDriverTopic, ConsumerGroup)
+	connDLQ := kfk.NewWriter(config.KafkaAddress, DeadLetterTopic)
 
 	// Создание объекта App
 	sugLog.Info("Creating app")
+	repo := repository.NewRepository(postgres)
+	relay := outbox.NewRelay(logger, repo, map[string]*kafka.Writer{
+		ToClientTopic: connClient,
+		ToDriverTopic: connDriver,
+	})
 	app := App{
 		ToClientTopic:         connClient,
 		ToDriverTopic:         connDriver,
 		FromClientDriverTopic: connClDrv,
+		DeadLetterTopic:       connDLQ,
 		Config:                config,
 		Logger:                logger,
 		Tracer:                tracer,
+		TracerProvider:        tracerProvider,
 		Postgres:              postgres,
+		Repository:            repo,
+		Adapter:               adapter.NewAdapter(logger, tracer, config, repo, requestsTotal, responseTime),
+		Relay:                 relay,
+		Cancellation:          cancellation.NewPolicy(config.CancellationPolicy),
 		RequestsTotal:         requestsTotal,
 		ResponseTime:          responseTime,
 	}
@@ -121,47 +195,190 @@ func NewApp(ctx context.Context) *App {
 	return &app
 }
 
+// Start обрабатывает команды, пока не завершится ctx. Возвращается после того, как
+// текущая команда обработана, а HTTP API и relay остановлены
 func (a *App) Start(ctx context.Context) {
-	for {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// HTTP API для чтения поездок
+	go func() {
+		defer wg.Done()
+		err := a.Adapter.Start(ctx)
+		if err != nil {
+			a.Logger.Sugar().Errorf("Adapter error. %v", err)
+		}
+	}()
+
+	// Публикация событий из outbox
+	go func() {
+		defer wg.Done()
+		a.Relay.Start(ctx)
+	}()
+
+	for ctx.Err() == nil {
 		a.iteration(ctx)
-		select {
-		case <-ctx.Done():
-			break
-		default:
+	}
+	a.Logger.Info("Consumer stopped")
+
+	wg.Wait()
+}
+
+// Shutdown закрывает соединения с Kafka и Postgres и отправляет оставшиеся span-ы в Jaeger
+func (a *App) Shutdown(ctx context.Context) {
+	a.Logger.Info("Shutting down")
+
+	// Reader сразу покидает группу, чтобы партиции перешли к другим репликам
+	err := a.FromClientDriverTopic.Close()
+	if err != nil {
+		a.Logger.Sugar().Errorf("Kafka reader close error. %v", err)
+	}
+	for _, writer := range []*kafka.Writer{a.ToClientTopic, a.ToDriverTopic, a.DeadLetterTopic} {
+		err = writer.Close()
+		if err != nil {
+			a.Logger.Sugar().Errorf("Kafka writer close error. %v", err)
 		}
 	}
+
+	err = a.Postgres.Close()
+	if err != nil {
+		a.Logger.Sugar().Errorf("Postgres close error. %v", err)
+	}
+
+	err = a.TracerProvider.Shutdown(ctx)
+	if err != nil {
+		a.Logger.Sugar().Errorf("Tracer provider shutdown error. %v", err)
+	}
+
+	a.Logger.Info("Shutdown complete")
+	_ = a.Logger.Sync()
 }
 
 func (a *App) iteration(ctx context.Context) {
-	ctx, span := a.Tracer.Start(ctx, "Iteration")
+	// Чтение из Kafka, прерывается при завершении ctx
+	message, err := kfk.ReadFromTopic(ctx, a.FromClientDriverTopic)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		a.Logger.Sugar().Errorf("Kafka read error. %v", err)
+		return
+	}
+	bytes := message.Value
+
+	// Прочитанная команда обрабатывается и коммитится до конца, даже если пришел сигнал остановки
+	ctx, span := a.Tracer.Start(context.WithoutCancel(ctx), "Iteration")
 	defer span.End()
 
-	// Чтение из Kafka
-	bytes, err := kfk.ReadFromTopic(a.FromClientDriverTopic)
+	// Обработка с повторами при временных ошибках
+	var fail *failure
+	attempts := 0
+	for attempts < maxAttempts {
+		attempts++
+		fail = a.handle(ctx, bytes)
+		if fail == nil || !fail.retryable {
+			break
+		}
+		time.Sleep(time.Duration(attempts) * retryDelay)
+	}
+
+	// Необработанное сообщение уходит в DLQ
+	if fail != nil {
+		err = a.deadLetter(ctx, &message, fail, attempts)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Kafka write error")
+			a.Logger.Sugar().Errorf("Dead letter write error, offset not committed. %v", err)
+			return
+		}
+	}
+
+	// Сообщение обработано или сохранено в DLQ
+	err = kfk.CommitMessage(ctx, a.FromClientDriverTopic, message)
 	if err != nil {
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "Kafka read error")
-		a.Logger.Sugar().Errorf("Kafka read error. %v", err)
-		return
+		span.SetStatus(codes.Error, "Kafka commit error")
+		a.Logger.Sugar().Errorf("Kafka commit error. %v", err)
 	}
+}
+
+// handle обрабатывает одну команду, при ошибке возвращает этап, на котором она произошла
+func (a *App) handle(ctx context.Context, bytes []byte) *failure {
+	span := trace.SpanFromContext(ctx)
 
 	// Десериализация запроса
 	var request models.Request
-	err = json.Unmarshal(bytes, &request)
+	err := json.Unmarshal(bytes, &request)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Unmarshal error")
 		a.Logger.Sugar().Errorf("Unmarshal error. %v", err)
-		return
+		return &failure{stage: stageUnmarshal, err: err}
 	}
 
 	// Проверка на тип Data
 	if request.DataContentType != "application/json" {
+		err = fmt.Errorf("unsupported datacontenttype %q", request.DataContentType)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Data type error")
 		a.Logger.Sugar().Errorf("Data type error. %v", err)
-		return
+		return &failure{stage: stageContentType, err: err}
+	}
+
+	// Повторно доставленная команда подтверждается без обработки
+	processed, err := a.Repository.IsProcessed(ctx, request.Id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres read error")
+		a.Logger.Sugar().Errorf("Postgres read error. %v", err)
+		return &failure{stage: stageDBRead, err: err, retryable: true}
+	}
+	if processed {
+		a.Logger.Sugar().Infof("Duplicate command skipped. %v", request.Id)
+		return nil
+	}
+
+	// Определение поездки, к которой относится команда
+	tripId, err := commandTripId(&request)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Data unmarshal error")
+		a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
+		return &failure{stage: stageData, err: err}
+	}
+
+	// Текущее состояние поездки
+	trip, err := a.Repository.GetTrip(ctx, tripId)
+	if errors.Is(err, repository.ErrTripNotFound) {
+		trip = &models.TripState{TripId: tripId, Status: state.None}
+	} else if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres read error")
+		a.Logger.Sugar().Errorf("Postgres read error. %v", err)
+		return &failure{stage: stageDBRead, err: err, retryable: true}
+	}
+
+	// Проверка допустимости перехода
+	current := trip.Status
+	status, err := state.Next(current, request.Type)
+	if errors.Is(err, state.ErrUnknownCommand) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Unknown command")
+		a.Logger.Sugar().Errorf("Unknown command. %v", err)
+		return &failure{stage: stageUnknownType, err: err}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Command rejected")
+		a.Logger.Sugar().Warnf("Command rejected. %v", err)
+		return a.reject(ctx, &request, tripId, current, err)
 	}
+	trip.Status = status
+
+	// Статистика
+	startTime := time.Now()
+	defer a.ResponseTime.WithLabelValues(request.Type).Set(float64(time.Now().Sub(startTime).Nanoseconds()))
+	a.RequestsTotal.WithLabelValues(request.Type).Inc()
 
 	response := models.Request{
 		Id:              request.Id,
@@ -171,131 +388,92 @@ func (a *App) iteration(ctx context.Context) {
 		Time:            request.Time,
 		Data:            nil, // будет заполнено далее
 	}
-	var conn []*kafka.Conn
+	history := models.Trip{
+		Id:              tripId,
+		Source:          response.Source,
+		DataContentType: response.DataContentType,
+		Time:            response.Time,
+		Status:          status,
+	}
+	var topics []string
+	var eventData models.Data
 	switch request.Type {
-	case "trip.command.accept":
-		// Статистика
-		startTime := time.Now()
-		defer a.ResponseTime.WithLabelValues("trip.command.accept").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
-		a.RequestsTotal.WithLabelValues("trip.command.accept").Inc()
-
+	case state.CommandAccept:
 		response.Type = "trip.event.accepted"
-		conn = make([]*kafka.Conn, 1)
-		conn[0] = a.ToClientTopic
+		topics = []string{ToClientTopic}
 
 		// Десериализация commandData
 		var commandData models.CommandAcceptData
-		err := json.Unmarshal(request.Data, &commandData)
+		err = json.Unmarshal(request.Data, &commandData)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Data unmarshal error")
 			a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
-			return
+			return &failure{stage: stageData, err: err}
 		}
 
-		// Сохранение в Postgres
-		a.Logger.Info("Writing to postgres")
-		err = sendPostgres(a.Postgres, &models.Trip{
-			Id:              response.Id,
-			Source:          response.Source,
-			Type:            response.Type,
-			DataContentType: response.DataContentType,
-			Time:            response.Time,
-			DriverId:        commandData.DriverId,
-			Status:          "DRIVER_FOUND",
-		})
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Postgres write error")
-			a.Logger.Sugar().Errorf("Postgres write error. %v", err)
-			return
-		}
-		a.Logger.Info("Written correctly")
+		trip.DriverId = commandData.DriverId
+		history.DriverId = commandData.DriverId
 
 		// Создание ответной data
-		eventData := models.EventAcceptData{
-			TripId: commandData.TripId,
-		}
-
-		// Сериализация eventData
-		response.Data, err = json.Marshal(eventData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data marshal error")
-			a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-			return
+		eventData = models.EventAcceptData{
+			TripId:   tripId,
+			DriverId: commandData.DriverId,
 		}
-	case "trip.command.cancel":
-		// Статистика
-		startTime := time.Now()
-		defer a.ResponseTime.WithLabelValues("trip.command.cancel").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
-		a.RequestsTotal.WithLabelValues("trip.command.cancel").Inc()
-
+	case state.CommandCancel:
 		response.Type = "trip.event.canceled"
-		conn = make([]*kafka.Conn, 1)
-		conn[0] = a.ToDriverTopic
+		topics = []string{ToDriverTopic, ToClientTopic}
 
 		// Десериализация commandData
 		var commandData models.CommandCancelData
-		err := json.Unmarshal(request.Data, &commandData)
+		err = json.Unmarshal(request.Data, &commandData)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Data unmarshal error")
 			a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
-			return
+			return &failure{stage: stageData, err: err}
 		}
 
-		// Сохранение в Postgres
-		a.Logger.Info("Writing to postgres")
-		err = sendPostgres(a.Postgres, &models.Trip{
-			Id:              response.Id,
-			Source:          response.Source,
-			Type:            response.Type,
-			DataContentType: response.DataContentType,
-			Time:            response.Time,
-			Reason:          commandData.Reason,
-			Status:          "CANCELED",
-		})
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Postgres write error")
-			a.Logger.Sugar().Errorf("Postgres write error. %v", err)
-			return
+		// Штраф по статусу из trips: SaveTransition проверяет версию, поэтому статус не мог измениться.
+		// Клиенту показан штраф по статусу из его проекции, списывается только он
+		amount, err := a.Cancellation.Fee(current, trip.Price.Amount)
+		var fee models.Price
+		if amount > 0 {
+			fee = models.Price{Amount: amount, Currency: trip.Price.Currency}
 		}
-		a.Logger.Info("Written correctly")
-
-		// Создание ответной data
-		eventData := models.EventCancelData{
-			TripId: commandData.TripId,
+		if err == nil && fee != commandData.Fee {
+			err = fmt.Errorf("%w: %v %v in status %v", cancellation.ErrFeeChanged, fee.Amount, fee.Currency, current)
 		}
-
-		// Сериализация eventData
-		response.Data, err = json.Marshal(eventData)
 		if err != nil {
 			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data marshal error")
-			a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-			return
+			span.SetStatus(codes.Error, "Command rejected")
+			a.Logger.Sugar().Warnf("Command rejected. %v", err)
+			return a.reject(ctx, &request, tripId, current, err)
 		}
-	case "trip.command.create":
-		// Статистика
-		startTime := time.Now()
-		defer a.ResponseTime.WithLabelValues("trip.command.create").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
-		a.RequestsTotal.WithLabelValues("trip.command.create").Inc()
 
+		history.Reason = commandData.Reason
+		history.CanceledBy = commandData.CanceledBy
+		history.Fee = fee
+
+		// Создание ответной data
+		eventData = models.EventCancelData{
+			TripId:     tripId,
+			Reason:     commandData.Reason,
+			CanceledBy: commandData.CanceledBy,
+			Fee:        fee,
+		}
+	case state.CommandCreate:
 		response.Type = "trip.event.created"
-		conn = make([]*kafka.Conn, 2)
-		conn[0] = a.ToDriverTopic
-		conn[1] = a.ToClientTopic
+		topics = []string{ToDriverTopic, ToClientTopic}
 
 		// Десериализация commandData
 		var commandData models.CommandCreateData
-		err := json.Unmarshal(request.Data, &commandData)
+		err = json.Unmarshal(request.Data, &commandData)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Data unmarshal error")
 			a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
-			return
+			return &failure{stage: stageData, err: err}
 		}
 
 		// Получение информации из OfferingService
@@ -304,152 +482,67 @@ func (a *App) iteration(ctx context.Context) {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Offer get error")
 			a.Logger.Sugar().Errorf("Offer get error. %v", err)
-			return
+			// Отказ offering (оффер невалиден или истек) повтором не исправить
+			var rejected *problem.Problem
+			retryable := !errors.As(err, &rejected) || rejected.Status >= http.StatusInternalServerError
+			return &failure{stage: stageOffer, err: err, retryable: retryable}
 		}
 
-		// Создание ответной data
-		eventData := models.EventCreateData{
-			TripId:  request.Id,
-			OfferId: commandData.OfferId,
-			Price:   order.Price,
-			Status:  "DRIVER_SEARCH",
-			From:    order.From,
-			To:      order.To,
-		}
+		trip.OfferId = commandData.OfferId
+		trip.Price = order.Price
+		trip.From = order.From
+		trip.To = order.To
+		trip.Waypoints = order.Waypoints
+		history.OfferId = commandData.OfferId
+		history.Price = order.Price
+		history.From = order.From
+		history.To = order.To
+		history.Waypoints = order.Waypoints
 
-		// Сохранение в Postgres
-		a.Logger.Info("Writing to postgres")
-		err = sendPostgres(a.Postgres, &models.Trip{
-			Id:              response.Id,
-			Source:          response.Source,
-			Type:            response.Type,
-			DataContentType: response.DataContentType,
-			Time:            response.Time,
-			OfferId:         eventData.OfferId,
-			Price:           eventData.Price,
-			From:            eventData.From,
-			To:              eventData.To,
-			Status:          "DRIVER_SEARCH",
-		})
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Postgres write error")
-			a.Logger.Sugar().Errorf("Postgres write error. %v", err)
-			return
+		// Создание ответной data
+		eventData = models.EventCreateData{
+			TripId:    tripId,
+			OfferId:   commandData.OfferId,
+			Price:     order.Price,
+			Status:    status,
+			From:      order.From,
+			To:        order.To,
+			Waypoints: order.Waypoints,
 		}
-		a.Logger.Info("Written correctly")
+	case state.CommandArrive:
+		response.Type = "trip.event.on_position"
+		topics = []string{ToClientTopic}
 
-		// Сериализация eventData
-		response.Data, err = json.Marshal(eventData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data marshal error")
-			a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-			return
+		// Создание ответной data
+		eventData = models.EventArriveData{
+			TripId: tripId,
 		}
-	case "trip.command.end":
-		// Статистика
-		startTime := time.Now()
-		defer a.ResponseTime.WithLabelValues("trip.command.end").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
-		a.RequestsTotal.WithLabelValues("trip.command.end").Inc()
-
+	case state.CommandEnd:
 		response.Type = "trip.event.ended"
-		conn = make([]*kafka.Conn, 1)
-		conn[0] = a.ToClientTopic
-
-		// Десериализация commandData
-		var commandData models.CommandEndData
-		err := json.Unmarshal(request.Data, &commandData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data unmarshal error")
-			a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
-			return
-		}
-
-		// Сохранение в Postgres
-		a.Logger.Info("Writing to postgres")
-		err = sendPostgres(a.Postgres, &models.Trip{
-			Id:              response.Id,
-			Source:          response.Source,
-			Type:            response.Type,
-			DataContentType: response.DataContentType,
-			Time:            response.Time,
-			Status:          "ENDED",
-		})
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Postgres write error")
-			a.Logger.Sugar().Errorf("Postgres write error. %v", err)
-			return
-		}
-		a.Logger.Info("Written correctly")
+		topics = []string{ToClientTopic}
 
 		// Создание ответной data
-		eventData := models.EventEndData{
-			TripId: commandData.TripId,
-		}
-
-		// Сериализация eventData
-		response.Data, err = json.Marshal(eventData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data marshal error")
-			a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-			return
+		eventData = models.EventEndData{
+			TripId: tripId,
 		}
-	case "trip.command.start":
-		// Статистика
-		startTime := time.Now()
-		defer a.ResponseTime.WithLabelValues("trip.command.start").Set(float64(time.Now().Sub(startTime).Nanoseconds()))
-		a.RequestsTotal.WithLabelValues("trip.command.start").Inc()
-
+	case state.CommandStart:
 		response.Type = "trip.event.started"
-		conn = make([]*kafka.Conn, 1)
-		conn[0] = a.ToClientTopic
-
-		// Десериализация commandData
-		var commandData models.CommandCancelData
-		err := json.Unmarshal(request.Data, &commandData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data unmarshal error")
-			a.Logger.Sugar().Errorf("Data unmarshal error. %v", err)
-			return
-		}
-
-		// Сохранение в Postgres
-		a.Logger.Info("Writing to postgres")
-		err = sendPostgres(a.Postgres, &models.Trip{
-			Id:              response.Id,
-			Source:          response.Source,
-			Type:            response.Type,
-			DataContentType: response.DataContentType,
-			Time:            response.Time,
-			Reason:          commandData.Reason,
-			Status:          "STARTED",
-		})
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Postgres write error")
-			a.Logger.Sugar().Errorf("Postgres write error. %v", err)
-			return
-		}
-		a.Logger.Info("Written correctly")
+		topics = []string{ToClientTopic}
 
 		// Создание ответной data
-		eventData := models.EventCancelData{
-			TripId: commandData.TripId,
+		eventData = models.EventStartData{
+			TripId: tripId,
 		}
+	}
+	history.Type = response.Type
 
-		// Сериализация eventData
-		response.Data, err = json.Marshal(eventData)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Data marshal error")
-			a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-			return
-		}
+	// Сериализация eventData
+	response.Data, err = json.Marshal(eventData)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Data marshal error")
+		a.Logger.Sugar().Errorf("Data marshal error. %v", err)
+		return &failure{stage: stageMarshal, err: err}
 	}
 
 	// Сериализация response
@@ -458,37 +551,176 @@ func (a *App) iteration(ctx context.Context) {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Data marshal error")
 		a.Logger.Sugar().Errorf("Data marshal error. %v", err)
-		return
+		return &failure{stage: stageMarshal, err: err}
 	}
 
-	// Запись в Kafka
-	for _, top := range conn {
-		err = kfk.SendToTopic(top, bytes)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, "Kafka write error")
-			a.Logger.Sugar().Errorf("Kafka write error. %v", err)
-			return
-		}
+	// Сохранение в Postgres вместе с событиями для outbox
+	a.Logger.Info("Writing to postgres")
+	err = a.Repository.SaveTransition(ctx, &request, trip, &history, outboxMessages(topics, tripId, bytes))
+	if errors.Is(err, repository.ErrDuplicateCommand) {
+		a.Logger.Sugar().Infof("Duplicate command skipped. %v", err)
+		return nil
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Version conflict")
+		a.Logger.Sugar().Warnf("Version conflict. %v", err)
+		return a.reject(ctx, &request, tripId, current, err)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Postgres write error")
+		a.Logger.Sugar().Errorf("Postgres write error. %v", err)
+		return &failure{stage: stageDBWrite, err: err, retryable: true}
+	}
+
+	a.Logger.Info("Written correctly, events queued")
+	return nil
+}
+
+// reject публикует событие об отклонении команды, недопустимой в текущем состоянии поездки
+func (a *App) reject(ctx context.Context, request *models.Request, tripId string, current string, reason error) *failure {
+	a.RequestsTotal.WithLabelValues("trip.event.rejected").Inc()
+
+	// Создание ответной data
+	eventData := models.EventRejectData{
+		TripId:  tripId,
+		Command: request.Type,
+		Status:  current,
+		Reason:  reason.Error(),
+	}
+
+	response := models.Request{
+		Id:              request.Id,
+		Source:          "/trip",
+		Type:            "trip.event.rejected",
+		DataContentType: "application/json",
+		Time:            request.Time,
+	}
+
+	// Сериализация eventData и response
+	var err error
+	response.Data, err = json.Marshal(eventData)
+	if err != nil {
+		a.Logger.Sugar().Errorf("Data marshal error. %v", err)
+		return &failure{stage: stageMarshal, err: err}
+	}
+	bytes, err := json.Marshal(response)
+	if err != nil {
+		a.Logger.Sugar().Errorf("Data marshal error. %v", err)
+		return &failure{stage: stageMarshal, err: err}
+	}
+
+	// Отклонение тоже результат обработки: повторная доставка не должна дать второе событие
+	err = a.Repository.MarkProcessed(ctx, request, tripId, outboxMessages(sourceTopics(request.Source), tripId, bytes))
+	if errors.Is(err, repository.ErrDuplicateCommand) {
+		a.Logger.Sugar().Infof("Duplicate command skipped. %v", err)
+		return nil
+	}
+	if err != nil {
+		a.Logger.Sugar().Errorf("Postgres write error. %v", err)
+		return &failure{stage: stageDBWrite, err: err, retryable: true}
+	}
+
+	a.Logger.Info("Reject queued")
+	return nil
+}
+
+// deadLetter отправляет необработанное сообщение в DLQ вместе с описанием ошибки
+func (a *App) deadLetter(ctx context.Context, message *kafka.Message, fail *failure, attempts int) error {
+	a.RequestsTotal.WithLabelValues("dead_letter").Inc()
+
+	bytes, err := json.Marshal(models.DeadLetter{
+		Service:     "trip",
+		SourceTopic: FromClientDriverTopic,
+		Key:         string(message.Key),
+		Stage:       fail.stage,
+		Error:       fail.err.Error(),
+		Attempts:    attempts,
+		FailedAt:    time.Now().UTC(),
+		Payload:     message.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = kfk.SendToTopic(ctx, a.DeadLetterTopic, string(message.Key), bytes)
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Sugar().Warnf("Message sent to DLQ at stage %v after %v attempts", fail.stage, attempts)
+	return nil
+}
+
+// outboxMessages создает по событию payload для каждого топика
+func outboxMessages(topics []string, tripId string, payload []byte) []models.OutboxMessage {
+	messages := make([]models.OutboxMessage, 0, len(topics))
+	for _, topic := range topics {
+		messages = append(messages, models.OutboxMessage{
+			Topic:   topic,
+			TripId:  tripId,
+			Payload: payload,
+		})
+	}
+	return messages
+}
+
+// sourceTopics возвращает топики, в которые нужно ответить источнику команды
+func sourceTopics(source string) []string {
+	switch source {
+	case "/client":
+		return []string{ToClientTopic}
+	case "/driver":
+		return []string{ToDriverTopic}
+	}
+	return []string{ToClientTopic, ToDriverTopic}
+}
+
+// commandTripId возвращает id поездки, к которой относится команда
+func commandTripId(request *models.Request) (string, error) {
+	// Для команды создания id поездки совпадает с id команды
+	if request.Type == state.CommandCreate {
+		return request.Id, nil
+	}
+
+	var data struct {
+		TripId string `json:"trip_id"`
+	}
+	err := json.Unmarshal(request.Data, &data)
+	if err != nil {
+		return "", err
+	}
+	if data.TripId == "" {
+		return "", fmt.Errorf("missing trip_id in %v", request.Type)
 	}
 
-	a.Logger.Info("Message sent")
+	return data.TripId, nil
 }
 
-// getOffer получает информацию о заказе из OfferingService
+// getOffer получает информацию о заказе из OfferingService. Ответ не 200 возвращается
+// как *problem.Problem со статусом ответа
 func (a *App) getOffer(offerID string) (*models.Order, error) {
 	// Запрос к OfferingService
 	resp, err := http.Get("http://" + a.Config.OfferingAddress + "/offers/" + offerID)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	// Чтение Body
 	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		rejected := problem.Parse(resp, bytes)
+		if rejected == nil {
+			rejected = problem.New(resp.StatusCode, problem.CodeOfferingUnavailable,
+				"Offering service responded "+resp.Status)
+		}
+		return nil, rejected
+	}
 
 	// Десериализация
 	var order models.Order
@@ -500,46 +732,67 @@ func (a *App) getOffer(offerID string) (*models.Order, error) {
 	return &order, nil
 }
 
-// sendPostgres сохраняет запись в postgres
-func sendPostgres(db *sql.DB, trip *models.Trip) error {
-	// SQL-запрос
-	query := `INSERT INTO trips_history
-  	(tripid, source, type, datacontenttype, time, driverid, reason, offerid, price, status, locfrom, locto)
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
-
-	// Сериализация объектов в string
-	bytes, err := json.Marshal(trip.Price)
+// Migrate выполняет подкоманду migrate: up | down [steps] | version
+func Migrate(ctx context.Context, args []string) error {
+	// Инициализация конфига
+	config, err := initConfig()
 	if err != nil {
 		return err
 	}
-	price := string(bytes)
 
-	bytes, err = json.Marshal(trip.From)
+	// Подключение к postgres
+	postgres, err := initPostgres(config.PostgresHost, config.PostgresPort, config.PostgresUser, config.PostgresPass)
 	if err != nil {
 		return err
 	}
-	from := string(bytes)
+	defer postgres.Close()
 
-	bytes, err = json.Marshal(trip.To)
+	migrator, err := migrate.NewMigrator(postgres, migrations.FS)
 	if err != nil {
 		return err
 	}
-	to := string(bytes)
 
-	// Выполнение запроса
-	_, err = db.Exec(query, trip.Id, trip.Source, trip.Type, trip.DataContentType, trip.Time, trip.DriverId, trip.Reason, trip.OfferId, price, trip.Status, from, to)
-	if err != nil {
-		return err
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | version")
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied migrations: %v", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %v", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reverted migrations: %v", reverted)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Schema version: %v", version)
+	default:
+		return fmt.Errorf("unknown migrate command %v, usage: migrate up | down [steps] | version", args[0])
 	}
 
 	return nil
 }
 
 // initJaeger подключает Jaeger для трейсинга
-func initJaeger(address string) error {
+func initJaeger(address string) (*tracesdk.TracerProvider, error) {
 	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint("http://" + address + "/api/traces")))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	tp := tracesdk.NewTracerProvider(
 		tracesdk.WithBatcher(exporter),
@@ -550,7 +803,7 @@ func initJaeger(address string) error {
 		)),
 	)
 	otel.SetTracerProvider(tp)
-	return nil
+	return tp, nil
 }
 
 // initPostgres инициализирует Postgres
@@ -570,26 +823,6 @@ func initPostgres(host string, port string, user string, password string) (*sql.
 		return nil, err
 	}
 
-	// Создание таблицы в postgres
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS trips_history (
-			"id" serial PRIMARY KEY,
-			"tripid" TEXT,
-    		"source" TEXT,
-    		"type" TEXT,
-    		"datacontenttype" TEXT,
-    		"time" TEXT,
-    		"driverid" TEXT,
-    		"reason" TEXT,
-    		"offerid" TEXT,
-    		"price" TEXT,
-    		"status" TEXT,
-    		"locfrom" TEXT,
-    		"locto" TEXT
-	)`)
-	if err != nil {
-		return nil, err
-	}
-
 	return db, nil
 }
 
@@ -2,39 +2,82 @@ package models
 
 import (
 	"encoding/json"
+	"shared/cancellation"
 	"time"
 )
 
 type Config struct {
 	KafkaAddress    string `json:"kafkaAddress"`
+	KafkaPartitions int    `json:"kafkaPartitions"`
 	OfferingAddress string `json:"offeringAddress"`
 	PostgresHost    string `json:"postgresHost"`
 	PostgresPort    string `json:"postgresPort"`
 	PostgresUser    string `json:"postgresUser"`
 	PostgresPass    string `json:"postgresPass"`
 	JaegerAddress   string `json:"jaegerAddress"`
+	ServeAddress    string `json:"serveAddress"`
+	// CancellationPolicy штраф за отмену по статусу поездки
+	CancellationPolicy map[string]cancellation.Rule `json:"cancellationPolicy"`
 }
 
 type Order struct {
-	From     Location `json:"from"`
-	To       Location `json:"to"`
-	ClientID string   `json:"client_id"`
-	Price    Price    `json:"price"`
+	From      Location   `json:"from"`
+	To        Location   `json:"to"`
+	Waypoints []Location `json:"waypoints,omitempty"`
+	ClientID  string     `json:"client_id"`
+	Price     Price      `json:"price"`
 }
 
 type Trip struct {
-	Id              string    `json:"id"`
-	Source          string    `json:"source"`
-	Type            string    `json:"type"`
-	DataContentType string    `json:"datacontenttype"`
-	Time            time.Time `json:"time"`
-	DriverId        string    `json:"driver_id"`
-	Reason          string    `json:"reason"`
-	OfferId         string    `json:"offer_id"`
-	Price           Price     `json:"price"`
-	Status          string    `json:"status"`
-	From            Location  `json:"from"`
-	To              Location  `json:"to"`
+	Id              string     `json:"id"`
+	Source          string     `json:"source"`
+	Type            string     `json:"type"`
+	DataContentType string     `json:"datacontenttype"`
+	Time            time.Time  `json:"time"`
+	DriverId        string     `json:"driver_id"`
+	Reason          string     `json:"reason"`
+	OfferId         string     `json:"offer_id"`
+	Price           Price      `json:"price"`
+	Status          string     `json:"status"`
+	From            Location   `json:"from"`
+	To              Location   `json:"to"`
+	Waypoints       []Location `json:"waypoints,omitempty"`
+	CanceledBy      string     `json:"canceled_by,omitempty"`
+	Fee             Price      `json:"fee"`
+}
+
+// TripState текущее состояние поездки (таблица trips)
+type TripState struct {
+	TripId    string     `json:"trip_id"`
+	OfferId   string     `json:"offer_id"`
+	DriverId  string     `json:"driver_id"`
+	Status    string     `json:"status"`
+	Price     Price      `json:"price"`
+	From      Location   `json:"from"`
+	To        Location   `json:"to"`
+	Waypoints []Location `json:"waypoints,omitempty"`
+	Version   int64      `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TripFilter условия выборки поездок, пустые поля не учитываются
+type TripFilter struct {
+	Status   string
+	DriverId string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+// OutboxMessage событие, ожидающее публикации в Kafka (таблица outbox)
+type OutboxMessage struct {
+	Id       int64
+	Topic    string
+	TripId   string
+	Payload  []byte
+	Attempts int
 }
 
 type Request struct {
@@ -53,15 +96,23 @@ type CommandAcceptData struct {
 	DriverId string `json:"driver_id"`
 }
 
+// CommandCancelData отмена поездки. Fee - штраф, показанный клиенту; он должен совпасть со штрафом
+// по статусу поездки в trips (config cancellationPolicy)
 type CommandCancelData struct {
-	TripId string `json:"trip_id"`
-	Reason string `json:"reason"`
+	TripId     string `json:"trip_id"`
+	Reason     string `json:"reason"`
+	CanceledBy string `json:"canceled_by"`
+	Fee        Price  `json:"fee"`
 }
 
 type CommandCreateData struct {
 	OfferId string `json:"offer_id"`
 }
 
+type CommandArriveData struct {
+	TripId string `json:"trip_id"`
+}
+
 type CommandEndData struct {
 	TripId string `json:"trip_id"`
 }
@@ -71,11 +122,15 @@ type CommandStartData struct {
 }
 
 type EventAcceptData struct {
-	TripId string `json:"trip_id"`
+	TripId   string `json:"trip_id"`
+	DriverId string `json:"driver_id"`
 }
 
 type EventCancelData struct {
-	TripId string `json:"trip_id"`
+	TripId     string `json:"trip_id"`
+	Reason     string `json:"reason"`
+	CanceledBy string `json:"canceled_by"`
+	Fee        Price  `json:"fee"`
 }
 
 type Location struct {
@@ -89,12 +144,17 @@ type Price struct {
 }
 
 type EventCreateData struct {
-	TripId  string   `json:"trip_id"`
-	OfferId string   `json:"offer_id"`
-	Price   Price    `json:"price"`
-	Status  string   `json:"status"`
-	From    Location `json:"from"`
-	To      Location `json:"to"`
+	TripId    string     `json:"trip_id"`
+	OfferId   string     `json:"offer_id"`
+	Price     Price      `json:"price"`
+	Status    string     `json:"status"`
+	From      Location   `json:"from"`
+	To        Location   `json:"to"`
+	Waypoints []Location `json:"waypoints,omitempty"`
+}
+
+type EventArriveData struct {
+	TripId string `json:"trip_id"`
 }
 
 type EventEndData struct {
@@ -104,3 +164,22 @@ type EventEndData struct {
 type EventStartData struct {
 	TripId string `json:"trip_id"`
 }
+
+type EventRejectData struct {
+	TripId  string `json:"trip_id"`
+	Command string `json:"command"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+}
+
+// DeadLetter сообщение DLQ: исходный payload и описание ошибки обработки
+type DeadLetter struct {
+	Service     string    `json:"service"`
+	SourceTopic string    `json:"source_topic"`
+	Key         string    `json:"key"`
+	Stage       string    `json:"stage"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	FailedAt    time.Time `json:"failed_at"`
+	Payload     []byte    `json:"payload"`
+}
@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"time"
+	"trip/internal/models"
+	"trip/internal/repository"
+	kfk "trip/pkg/kafka"
+)
+
+const (
+	batchSize     = 100
+	pollInterval  = 500 * time.Millisecond
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+)
+
+// Relay публикует события из таблицы outbox в Kafka
+type Relay struct {
+	repository *repository.Repository
+	topics     map[string]*kafka.Writer
+	Logger     *zap.Logger
+}
+
+func NewRelay(logger *zap.Logger, repository *repository.Repository, topics map[string]*kafka.Writer) *Relay {
+	return &Relay{
+		repository: repository,
+		topics:     topics,
+		Logger:     logger,
+	}
+}
+
+// Start публикует события, пока не завершится ctx. Начатая пачка доставляется до конца,
+// чтобы отправленные в Kafka события успели отметиться в outbox
+func (r *Relay) Start(ctx context.Context) {
+	r.Logger.Info("Starting outbox relay")
+	batchCtx := context.WithoutCancel(ctx)
+	for {
+		processed, err := r.repository.DeliverOutbox(batchCtx, batchSize, func(message *models.OutboxMessage) error {
+			return r.send(batchCtx, message)
+		}, retryDelay)
+		if err != nil {
+			r.Logger.Sugar().Errorf("Outbox relay error. %v", err)
+		}
+
+		// Полная пачка - вероятно, есть еще события, ждать не нужно
+		if err == nil && processed == batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			r.Logger.Info("Outbox relay stopped")
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// send отправляет событие в его топик. Ключ - id поездки, чтобы события поездки шли по порядку
+func (r *Relay) send(ctx context.Context, message *models.OutboxMessage) error {
+	writer, ok := r.topics[message.Topic]
+	if !ok {
+		return fmt.Errorf("unknown topic %v", message.Topic)
+	}
+
+	err := kfk.SendToTopic(ctx, writer, message.TripId, message.Payload)
+	if err != nil {
+		r.Logger.Sugar().Warnf("Outbox message %v delivery failed (attempt %v). %v", message.Id, message.Attempts+1, err)
+		return err
+	}
+
+	return nil
+}
+
+// retryDelay экспоненциальная задержка перед повторной отправкой
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
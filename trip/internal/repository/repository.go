@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trip/internal/models"
+)
+
+// ErrTripNotFound возвращается, если поездки нет в таблице trips
+var ErrTripNotFound = errors.New("trip not found")
+
+// ErrVersionConflict возвращается, если поездку успел изменить кто-то другой
+var ErrVersionConflict = errors.New("trip version conflict")
+
+// ErrDuplicateCommand возвращается, если команда с таким id уже была обработана
+var ErrDuplicateCommand = errors.New("duplicate command")
+
+// Repository хранилище поездок в Postgres
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// tripColumns колонки таблицы trips в порядке scanTrip
+const tripColumns = `trip_id, offer_id, driver_id, status, price_amount, price_currency,
+       from_lat, from_lng, to_lat, to_lng, waypoints, version, created_at, updated_at`
+
+// GetTrip возвращает текущее состояние поездки из таблицы trips
+func (r *Repository) GetTrip(ctx context.Context, tripId string) (*models.TripState, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+tripColumns+` FROM trips WHERE trip_id = $1`, tripId)
+
+	trip, err := scanTrip(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTripNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return trip, nil
+}
+
+// ListTrips возвращает поездки, подходящие под filter, от новых к старым
+func (r *Repository) ListTrips(ctx context.Context, filter *models.TripFilter) ([]models.TripState, error) {
+	// Сборка условий запроса
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Status != "" {
+		addCondition("status = $%v", filter.Status)
+	}
+	if filter.DriverId != "" {
+		addCondition("driver_id = $%v", filter.DriverId)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%v", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%v", filter.To)
+	}
+
+	query := `SELECT ` + tripColumns + ` FROM trips`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, trip_id LIMIT $%v OFFSET $%v`, len(args)-1, len(args))
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trips := make([]models.TripState, 0)
+	for rows.Next() {
+		trip, err := scanTrip(rows)
+		if err != nil {
+			return nil, err
+		}
+		trips = append(trips, *trip)
+	}
+
+	return trips, rows.Err()
+}
+
+// ListEvents возвращает историю поездки из trips_history в порядке записи
+func (r *Repository) ListEvents(ctx context.Context, tripId string) ([]models.Trip, error) {
+	query := `SELECT trip_id, source, type, data_content_type, time, driver_id, reason, offer_id,
+       price_amount, price_currency, status, from_lat, from_lng, to_lat, to_lng,
+       canceled_by, fee_amount, fee_currency, waypoints
+	FROM trips_history WHERE trip_id = $1 ORDER BY id`
+
+	rows, err := r.DB.QueryContext(ctx, query, tripId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.Trip, 0)
+	for rows.Next() {
+		var event models.Trip
+		var driverId, reason, offerId, currency, canceledBy, feeCurrency sql.NullString
+		var amount, fromLat, fromLng, toLat, toLng, feeAmount sql.NullFloat64
+		var waypoints []byte
+		err := rows.Scan(&event.Id, &event.Source, &event.Type, &event.DataContentType, &event.Time,
+			&driverId, &reason, &offerId, &amount, &currency, &event.Status, &fromLat, &fromLng, &toLat, &toLng,
+			&canceledBy, &feeAmount, &feeCurrency, &waypoints)
+		if err != nil {
+			return nil, err
+		}
+		event.Waypoints, err = scanWaypoints(waypoints)
+		if err != nil {
+			return nil, err
+		}
+
+		// NULL превращается в пустые значения
+		event.DriverId = driverId.String
+		event.Reason = reason.String
+		event.OfferId = offerId.String
+		event.Price = models.Price{Amount: amount.Float64, Currency: strings.TrimSpace(currency.String)}
+		event.From = models.Location{Lat: fromLat.Float64, Lng: fromLng.Float64}
+		event.To = models.Location{Lat: toLat.Float64, Lng: toLng.Float64}
+		event.CanceledBy = canceledBy.String
+		event.Fee = models.Price{Amount: feeAmount.Float64, Currency: strings.TrimSpace(feeCurrency.String)}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// scanner общий интерфейс sql.Row и sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTrip читает строку таблицы trips
+func scanTrip(row scanner) (*models.TripState, error) {
+	var trip models.TripState
+	var waypoints []byte
+	err := row.Scan(
+		&trip.TripId, &trip.OfferId, &trip.DriverId, &trip.Status, &trip.Price.Amount, &trip.Price.Currency,
+		&trip.From.Lat, &trip.From.Lng, &trip.To.Lat, &trip.To.Lng, &waypoints, &trip.Version, &trip.CreatedAt, &trip.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	trip.Waypoints, err = scanWaypoints(waypoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+// IsProcessed сообщает, была ли команда с таким id уже обработана
+func (r *Repository) IsProcessed(ctx context.Context, commandId string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM processed_commands WHERE command_id = $1)`, commandId).Scan(&exists)
+	return exists, err
+}
+
+// MarkProcessed отмечает команду обработанной без изменения поездки (например, при отклонении).
+// Если команда уже была отмечена, возвращается ErrDuplicateCommand.
+// В той же транзакции events ставятся в outbox.
+func (r *Repository) MarkProcessed(ctx context.Context, command *models.Request, tripId string, events []models.OutboxMessage) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = markProcessed(ctx, tx, command, tripId)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutbox(ctx, tx, events)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveTransition в одной транзакции отмечает команду обработанной, обновляет поездку в trips,
+// добавляет запись в trips_history и ставит events в outbox.
+// Повторная команда с тем же id не меняет ничего и возвращает ErrDuplicateCommand.
+// Обновление проходит только если версия в базе совпадает с trip.Version, иначе возвращается ErrVersionConflict.
+// После успешного сохранения trip.Version увеличивается на единицу.
+func (r *Repository) SaveTransition(ctx context.Context, command *models.Request, trip *models.TripState, history *models.Trip,
+	events []models.OutboxMessage) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Дедупликация по id команды
+	err = markProcessed(ctx, tx, command, trip.TripId)
+	if err != nil {
+		return err
+	}
+
+	// Обновление текущего состояния
+	updatedAt := time.Now().UTC()
+	err = saveTrip(ctx, tx, trip, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Запись в историю
+	err = insertHistory(ctx, tx, history)
+	if err != nil {
+		return err
+	}
+
+	// Исходящие события
+	err = insertOutbox(ctx, tx, events)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	if trip.Version == 0 {
+		trip.CreatedAt = updatedAt
+	}
+	trip.Version++
+	trip.UpdatedAt = updatedAt
+	return nil
+}
+
+// DeliverOutbox берет до limit готовых к отправке событий и передает их в send.
+// Для каждой пары (trip_id, topic) выбирается только самое раннее недоставленное событие,
+// поэтому порядок событий поездки сохраняется даже при нескольких репликах.
+// Успешно отправленные события отмечаются доставленными, остальные откладываются на retryDelay.
+// Возвращает число обработанных событий.
+func (r *Repository) DeliverOutbox(ctx context.Context, limit int, send func(message *models.OutboxMessage) error,
+	retryDelay func(attempts int) time.Duration) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, topic, trip_id, payload, attempts FROM outbox o
+	WHERE delivered_at IS NULL AND next_attempt_at <= now()
+	  AND NOT EXISTS (SELECT 1 FROM outbox p
+	                  WHERE p.trip_id = o.trip_id AND p.topic = o.topic AND p.delivered_at IS NULL AND p.id < o.id)
+	ORDER BY id
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	var messages []models.OutboxMessage
+	for rows.Next() {
+		var message models.OutboxMessage
+		err = rows.Scan(&message.Id, &message.Topic, &message.TripId, &message.Payload, &message.Attempts)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, message)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range messages {
+		message := &messages[i]
+		sendErr := send(message)
+		if sendErr == nil {
+			_, err = tx.ExecContext(ctx, `UPDATE outbox SET delivered_at = now(), attempts = attempts + 1, last_error = NULL
+			WHERE id = $1`, message.Id)
+		} else {
+			delay := retryDelay(message.Attempts + 1)
+			_, err = tx.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $2,
+			next_attempt_at = now() + $3 * interval '1 millisecond' WHERE id = $1`,
+				message.Id, sendErr.Error(), delay.Milliseconds())
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(messages), nil
+}
+
+// insertOutbox ставит события в очередь на публикацию
+func insertOutbox(ctx context.Context, tx *sql.Tx, events []models.OutboxMessage) error {
+	for _, event := range events {
+		_, err := tx.ExecContext(ctx, `INSERT INTO outbox (topic, trip_id, payload) VALUES ($1, $2, $3)`,
+			event.Topic, event.TripId, event.Payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markProcessed записывает id команды в processed_commands
+func markProcessed(ctx context.Context, tx *sql.Tx, command *models.Request, tripId string) error {
+	result, err := tx.ExecContext(ctx, `INSERT INTO processed_commands (command_id, type, trip_id) VALUES ($1, $2, $3)
+	ON CONFLICT (command_id) DO NOTHING`, command.Id, command.Type, tripId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %v", ErrDuplicateCommand, command.Id)
+	}
+
+	return nil
+}
+
+// saveTrip создает (trip.Version == 0) или обновляет поездку с проверкой версии
+func saveTrip(ctx context.Context, tx *sql.Tx, trip *models.TripState, updatedAt time.Time) error {
+	var query string
+	if trip.Version == 0 {
+		query = `INSERT INTO trips
+  	(trip_id, offer_id, driver_id, status, price_amount, price_currency, from_lat, from_lng, to_lat, to_lng,
+  	 waypoints, version, updated_at, created_at)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
+	ON CONFLICT (trip_id) DO NOTHING`
+	} else {
+		query = `UPDATE trips SET
+	offer_id = $2, driver_id = $3, status = $4, price_amount = $5, price_currency = $6,
+	from_lat = $7, from_lng = $8, to_lat = $9, to_lng = $10, waypoints = $11, version = $12, updated_at = $13
+	WHERE trip_id = $1 AND version = $14`
+	}
+
+	waypoints, err := jsonWaypoints(trip.Waypoints)
+	if err != nil {
+		return err
+	}
+	args := []interface{}{trip.TripId, trip.OfferId, trip.DriverId, trip.Status,
+		trip.Price.Amount, trip.Price.Currency, trip.From.Lat, trip.From.Lng, trip.To.Lat, trip.To.Lng,
+		waypoints, trip.Version + 1, updatedAt}
+	if trip.Version != 0 {
+		args = append(args, trip.Version)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	// Ни одной строки не изменено - версия устарела
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: trip %v was modified concurrently (expected version %v)", ErrVersionConflict, trip.TripId, trip.Version)
+	}
+
+	return nil
+}
+
+// insertHistory сохраняет запись в trips_history
+func insertHistory(ctx context.Context, tx *sql.Tx, trip *models.Trip) error {
+	// SQL-запрос
+	query := `INSERT INTO trips_history
+  	(trip_id, source, type, data_content_type, time, driver_id, reason, offer_id,
+  	 price_amount, price_currency, status, from_lat, from_lng, to_lat, to_lng,
+  	 canceled_by, fee_amount, fee_currency, waypoints)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)`
+
+	// Незаполненные поля сохраняются как NULL
+	amount, currency := nullPrice(trip.Price)
+	feeAmount, feeCurrency := nullPrice(trip.Fee)
+	fromLat, fromLng := nullLocation(trip.From)
+	toLat, toLng := nullLocation(trip.To)
+	// Остановки есть только у события создания
+	var waypoints sql.NullString
+	if len(trip.Waypoints) > 0 {
+		var err error
+		waypoints.String, err = jsonWaypoints(trip.Waypoints)
+		if err != nil {
+			return err
+		}
+		waypoints.Valid = true
+	}
+
+	// Выполнение запроса
+	_, err := tx.ExecContext(ctx, query, trip.Id, trip.Source, trip.Type, trip.DataContentType, trip.Time,
+		nullString(trip.DriverId), nullString(trip.Reason), nullString(trip.OfferId),
+		amount, currency, trip.Status, fromLat, fromLng, toLat, toLng,
+		nullString(trip.CanceledBy), feeAmount, feeCurrency, waypoints)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// nullString превращает пустую строку в NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// jsonWaypoints сериализует остановки для колонки jsonb, nil - пустой массив.
+// Строка, а не []byte: lib/pq передает []byte как bytea
+func jsonWaypoints(waypoints []models.Location) (string, error) {
+	if waypoints == nil {
+		waypoints = []models.Location{}
+	}
+	bytes, err := json.Marshal(waypoints)
+	return string(bytes), err
+}
+
+// scanWaypoints читает остановки из jsonb, NULL - нет остановок
+func scanWaypoints(value []byte) ([]models.Location, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var waypoints []models.Location
+	err := json.Unmarshal(value, &waypoints)
+	if err != nil {
+		return nil, err
+	}
+	if len(waypoints) == 0 {
+		return nil, nil
+	}
+	return waypoints, nil
+}
+
+// nullPrice превращает цену без валюты в пару NULL
+func nullPrice(price models.Price) (sql.NullFloat64, sql.NullString) {
+	if price.Currency == "" {
+		return sql.NullFloat64{}, sql.NullString{}
+	}
+	return sql.NullFloat64{Float64: price.Amount, Valid: true}, sql.NullString{String: price.Currency, Valid: true}
+}
+
+// nullLocation превращает незаполненную точку в пару NULL
+func nullLocation(location models.Location) (sql.NullFloat64, sql.NullFloat64) {
+	if location == (models.Location{}) {
+		return sql.NullFloat64{}, sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: location.Lat, Valid: true}, sql.NullFloat64{Float64: location.Lng, Valid: true}
+}
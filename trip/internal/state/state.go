@@ -0,0 +1,85 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Состояния поездки (соответствуют enum status из контракта client)
+const (
+	None         = ""
+	DriverSearch = "DRIVER_SEARCH"
+	DriverFound  = "DRIVER_FOUND"
+	OnPosition   = "ON_POSITION"
+	Started      = "STARTED"
+	Ended        = "ENDED"
+	Canceled     = "CANCELED"
+)
+
+// Команды, меняющие состояние поездки
+const (
+	CommandCreate = "trip.command.create"
+	CommandAccept = "trip.command.accept"
+	CommandArrive = "trip.command.arrive"
+	CommandStart  = "trip.command.start"
+	CommandEnd    = "trip.command.end"
+	CommandCancel = "trip.command.cancel"
+)
+
+// ErrIllegalTransition возвращается, если команда недопустима в текущем состоянии
+var ErrIllegalTransition = errors.New("illegal transition")
+
+// ErrUnknownCommand возвращается для команды, не известной автомату
+var ErrUnknownCommand = errors.New("unknown command")
+
+// transitions таблица переходов: состояние -> команда -> новое состояние
+var transitions = map[string]map[string]string{
+	None: {
+		CommandCreate: DriverSearch,
+	},
+	DriverSearch: {
+		CommandAccept: DriverFound,
+		CommandCancel: Canceled,
+	},
+	DriverFound: {
+		CommandArrive: OnPosition,
+		CommandStart:  Started,
+		CommandCancel: Canceled,
+	},
+	OnPosition: {
+		CommandStart:  Started,
+		CommandCancel: Canceled,
+	},
+	Started: {
+		CommandEnd: Ended,
+	},
+	Ended:    {},
+	Canceled: {},
+}
+
+// commands множество всех известных команд
+var commands = map[string]bool{
+	CommandCreate: true,
+	CommandAccept: true,
+	CommandArrive: true,
+	CommandStart:  true,
+	CommandEnd:    true,
+	CommandCancel: true,
+}
+
+// Next возвращает состояние, в которое переходит поездка из current по команде command
+func Next(current string, command string) (string, error) {
+	if !commands[command] {
+		return "", fmt.Errorf("%w: %v", ErrUnknownCommand, command)
+	}
+
+	next, ok := transitions[current][command]
+	if !ok {
+		if current == None {
+			return "", fmt.Errorf("%w: %v is not allowed for a trip that does not exist", ErrIllegalTransition, command)
+		}
+		return "", fmt.Errorf("%w: %v is not allowed in state %v", ErrIllegalTransition, command, current)
+	}
+
+	return next, nil
+}
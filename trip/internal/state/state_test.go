@@ -0,0 +1,49 @@
+package state
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		current string
+		command string
+		want    string
+		err     error
+	}{
+		{None, CommandCreate, DriverSearch, nil},
+		{None, CommandAccept, "", ErrIllegalTransition},
+		{None, CommandCancel, "", ErrIllegalTransition},
+		{DriverSearch, CommandAccept, DriverFound, nil},
+		{DriverSearch, CommandCancel, Canceled, nil},
+		{DriverSearch, CommandCreate, "", ErrIllegalTransition},
+		{DriverSearch, CommandStart, "", ErrIllegalTransition},
+		{DriverFound, CommandArrive, OnPosition, nil},
+		{DriverFound, CommandStart, Started, nil},
+		{DriverFound, CommandCancel, Canceled, nil},
+		{DriverFound, CommandAccept, "", ErrIllegalTransition},
+		{DriverFound, CommandEnd, "", ErrIllegalTransition},
+		{OnPosition, CommandStart, Started, nil},
+		{OnPosition, CommandCancel, Canceled, nil},
+		{OnPosition, CommandArrive, "", ErrIllegalTransition},
+		{Started, CommandEnd, Ended, nil},
+		{Started, CommandCancel, "", ErrIllegalTransition},
+		{Ended, CommandCancel, "", ErrIllegalTransition},
+		{Ended, CommandEnd, "", ErrIllegalTransition},
+		{Canceled, CommandCancel, "", ErrIllegalTransition},
+		{Canceled, CommandAccept, "", ErrIllegalTransition},
+		{DriverSearch, "trip.command.unknown", "", ErrUnknownCommand},
+		{None, "", "", ErrUnknownCommand},
+	}
+
+	for _, test := range tests {
+		got, err := Next(test.current, test.command)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Next(%q, %q) error = %v, want %v", test.current, test.command, err, test.err)
+		}
+		if got != test.want {
+			t.Errorf("Next(%q, %q) = %q, want %q", test.current, test.command, got, test.want)
+		}
+	}
+}
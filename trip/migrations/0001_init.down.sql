@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS trips;
+DROP TABLE IF EXISTS trips_history;
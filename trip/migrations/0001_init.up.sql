@@ -0,0 +1,34 @@
+-- Исходная схема: история с текстовыми колонками и таблица текущих состояний.
+-- IF NOT EXISTS позволяет принять базы, созданные до появления миграций.
+-- Поездки, созданные до появления trips, в нее не восстанавливаются: до этого в tripid
+-- записывался id команды, а не поездки, и по истории нельзя собрать состояние поездки.
+CREATE TABLE IF NOT EXISTS trips_history (
+    "id" serial PRIMARY KEY,
+    "tripid" TEXT,
+    "source" TEXT,
+    "type" TEXT,
+    "datacontenttype" TEXT,
+    "time" TEXT,
+    "driverid" TEXT,
+    "reason" TEXT,
+    "offerid" TEXT,
+    "price" TEXT,
+    "status" TEXT,
+    "locfrom" TEXT,
+    "locto" TEXT
+);
+
+CREATE TABLE IF NOT EXISTS trips (
+    "trip_id" TEXT PRIMARY KEY,
+    "offer_id" TEXT NOT NULL DEFAULT '',
+    "driver_id" TEXT NOT NULL DEFAULT '',
+    "status" TEXT NOT NULL,
+    "price_amount" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "price_currency" TEXT NOT NULL DEFAULT '',
+    "from_lat" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "from_lng" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "to_lat" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "to_lng" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "version" BIGINT NOT NULL,
+    "updated_at" TIMESTAMPTZ NOT NULL
+);
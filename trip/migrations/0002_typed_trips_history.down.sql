@@ -0,0 +1,48 @@
+ALTER TABLE trips ALTER COLUMN price_amount TYPE DOUBLE PRECISION;
+
+ALTER TABLE trips_history RENAME TO trips_history_typed;
+
+CREATE TABLE trips_history (
+    "id" serial PRIMARY KEY,
+    "tripid" TEXT,
+    "source" TEXT,
+    "type" TEXT,
+    "datacontenttype" TEXT,
+    "time" TEXT,
+    "driverid" TEXT,
+    "reason" TEXT,
+    "offerid" TEXT,
+    "price" TEXT,
+    "status" TEXT,
+    "locfrom" TEXT,
+    "locto" TEXT
+);
+
+INSERT INTO trips_history (id, tripid, source, type, datacontenttype, time, driverid, reason, offerid,
+                           price, status, locfrom, locto)
+SELECT id,
+       trip_id,
+       source,
+       type,
+       data_content_type,
+       time::text,
+       COALESCE(driver_id, ''),
+       COALESCE(reason, ''),
+       COALESCE(offer_id, ''),
+       json_build_object('amount', COALESCE(price_amount, 0), 'currency', COALESCE(price_currency, ''))::text,
+       status,
+       json_build_object('lat', COALESCE(from_lat, 0), 'lng', COALESCE(from_lng, 0))::text,
+       json_build_object('lat', COALESCE(to_lat, 0), 'lng', COALESCE(to_lng, 0))::text
+FROM trips_history_typed
+ORDER BY id;
+
+INSERT INTO trips_history (id, tripid, source, type, datacontenttype, time, driverid, reason, offerid,
+                           price, status, locfrom, locto)
+SELECT id, tripid, source, type, datacontenttype, time, driverid, reason, offerid, price, status, locfrom, locto
+FROM trips_history_unlinked
+ORDER BY id;
+
+SELECT setval(pg_get_serial_sequence('trips_history', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM trips_history;
+
+DROP TABLE trips_history_typed;
+DROP TABLE trips_history_unlinked;
@@ -0,0 +1,60 @@
+-- Перевод trips_history на типизированные колонки с переносом существующих данных
+ALTER TABLE trips_history RENAME TO trips_history_legacy;
+
+CREATE TABLE trips_history (
+    id BIGSERIAL PRIMARY KEY,
+    trip_id TEXT NOT NULL,
+    source TEXT NOT NULL,
+    type TEXT NOT NULL,
+    data_content_type TEXT NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    driver_id TEXT,
+    reason TEXT,
+    offer_id TEXT,
+    price_amount NUMERIC,
+    price_currency CHAR(3),
+    status TEXT NOT NULL,
+    from_lat DOUBLE PRECISION,
+    from_lng DOUBLE PRECISION,
+    to_lat DOUBLE PRECISION,
+    to_lng DOUBLE PRECISION,
+    CONSTRAINT trips_history_price_check CHECK ((price_amount IS NULL) = (price_currency IS NULL)),
+    CONSTRAINT trips_history_from_check CHECK ((from_lat IS NULL) = (from_lng IS NULL)),
+    CONSTRAINT trips_history_to_check CHECK ((to_lat IS NULL) = (to_lng IS NULL))
+);
+
+-- Переносятся только строки поездок из trips. В строках, записанных до появления trips, tripid
+-- хранит id команды, а у поездки есть только строка создания: такие строки не отдаются
+-- по /trips/{id}/events и остаются в trips_history_unlinked как есть.
+-- Пустые цены и нулевые координаты в старых строках означали отсутствие значения
+INSERT INTO trips_history (id, trip_id, source, type, data_content_type, time, driver_id, reason, offer_id,
+                           price_amount, price_currency, status, from_lat, from_lng, to_lat, to_lng)
+SELECT id,
+       COALESCE(tripid, ''),
+       COALESCE(source, ''),
+       COALESCE(type, ''),
+       COALESCE(datacontenttype, ''),
+       COALESCE(NULLIF(time, '')::timestamptz, 'epoch'::timestamptz),
+       NULLIF(driverid, ''),
+       NULLIF(reason, ''),
+       NULLIF(offerid, ''),
+       CASE WHEN COALESCE(NULLIF(price, '')::jsonb ->> 'currency', '') <> '' THEN (NULLIF(price, '')::jsonb ->> 'amount')::numeric END,
+       CASE WHEN COALESCE(NULLIF(price, '')::jsonb ->> 'currency', '') <> '' THEN NULLIF(price, '')::jsonb ->> 'currency' END,
+       COALESCE(status, ''),
+       CASE WHEN NULLIF(locfrom, '')::jsonb <> '{"lat": 0, "lng": 0}'::jsonb THEN (NULLIF(locfrom, '')::jsonb ->> 'lat')::double precision END,
+       CASE WHEN NULLIF(locfrom, '')::jsonb <> '{"lat": 0, "lng": 0}'::jsonb THEN (NULLIF(locfrom, '')::jsonb ->> 'lng')::double precision END,
+       CASE WHEN NULLIF(locto, '')::jsonb <> '{"lat": 0, "lng": 0}'::jsonb THEN (NULLIF(locto, '')::jsonb ->> 'lat')::double precision END,
+       CASE WHEN NULLIF(locto, '')::jsonb <> '{"lat": 0, "lng": 0}'::jsonb THEN (NULLIF(locto, '')::jsonb ->> 'lng')::double precision END
+FROM trips_history_legacy
+WHERE tripid IN (SELECT trip_id FROM trips)
+ORDER BY id;
+
+SELECT setval(pg_get_serial_sequence('trips_history', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM trips_history;
+
+DELETE FROM trips_history_legacy WHERE tripid IN (SELECT trip_id FROM trips);
+ALTER TABLE trips_history_legacy RENAME TO trips_history_unlinked;
+
+CREATE INDEX trips_history_trip_id_idx ON trips_history (trip_id, id);
+CREATE INDEX trips_history_time_idx ON trips_history (time);
+
+ALTER TABLE trips ALTER COLUMN price_amount TYPE NUMERIC;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS trips_driver_id_idx;
+DROP INDEX IF EXISTS trips_status_idx;
+DROP INDEX IF EXISTS trips_created_at_idx;
+
+ALTER TABLE trips DROP COLUMN created_at;
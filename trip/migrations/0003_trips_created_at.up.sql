@@ -0,0 +1,11 @@
+-- Время создания поездки для фильтрации по периоду
+ALTER TABLE trips ADD COLUMN created_at TIMESTAMPTZ;
+
+UPDATE trips t
+SET created_at = COALESCE((SELECT MIN(h.time) FROM trips_history h WHERE h.trip_id = t.trip_id), t.updated_at);
+
+ALTER TABLE trips ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX trips_created_at_idx ON trips (created_at);
+CREATE INDEX trips_status_idx ON trips (status, created_at);
+CREATE INDEX trips_driver_id_idx ON trips (driver_id, created_at);
@@ -0,0 +1,7 @@
+-- Обработанные команды для идемпотентной обработки повторных доставок из Kafka
+CREATE TABLE processed_commands (
+    command_id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    trip_id TEXT NOT NULL,
+    processed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
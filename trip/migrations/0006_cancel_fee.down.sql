@@ -0,0 +1,4 @@
+ALTER TABLE trips_history DROP CONSTRAINT IF EXISTS trips_history_fee_check;
+ALTER TABLE trips_history DROP COLUMN IF EXISTS fee_currency;
+ALTER TABLE trips_history DROP COLUMN IF EXISTS fee_amount;
+ALTER TABLE trips_history DROP COLUMN IF EXISTS canceled_by;
@@ -0,0 +1,5 @@
+-- Кто отменил поездку и штраф за отмену по политике client
+ALTER TABLE trips_history ADD COLUMN canceled_by TEXT;
+ALTER TABLE trips_history ADD COLUMN fee_amount NUMERIC;
+ALTER TABLE trips_history ADD COLUMN fee_currency CHAR(3);
+ALTER TABLE trips_history ADD CONSTRAINT trips_history_fee_check CHECK ((fee_amount IS NULL) = (fee_currency IS NULL));
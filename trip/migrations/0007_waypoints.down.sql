@@ -0,0 +1,2 @@
+ALTER TABLE trips_history DROP COLUMN IF EXISTS waypoints;
+ALTER TABLE trips DROP COLUMN IF EXISTS waypoints;
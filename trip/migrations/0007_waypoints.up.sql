@@ -0,0 +1,3 @@
+-- Промежуточные остановки маршрута в порядке объезда
+ALTER TABLE trips ADD COLUMN waypoints JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE trips_history ADD COLUMN waypoints JSONB;
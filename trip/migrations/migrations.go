@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS SQL-миграции схемы Postgres сервиса trip.
+// Файлы именуются как NNNN_name.up.sql и NNNN_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS